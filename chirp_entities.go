@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/entities"
+)
+
+// start and end are grapheme cluster offsets into the chirp body, the same
+// unit its length is counted in
+type ChirpEntities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+type HashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+type MentionEntity struct {
+	Handle string     `json:"handle"`
+	UserID *uuid.UUID `json:"user_id"`
+	Start  int32      `json:"start"`
+	End    int32      `json:"end"`
+}
+
+// parse the hashtags and mentions out of a chirp body and store them,
+// replacing whatever was stored for the chirp before
+func storeChirpEntities(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	err := q.DeleteChirpHashtags(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = q.DeleteChirpMentions(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range entities.Parse(body) {
+		switch entity.Kind {
+		case entities.Hashtag:
+			hashtag, err := q.UpsertHashtag(ctx, database.UpsertHashtagParams{
+				ID:        uuid.New(),
+				Tag:       entity.Text,
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+				ChirpID:     chirpID,
+				HashtagID:   hashtag.ID,
+				StartOffset: int32(entity.Start),
+				EndOffset:   int32(entity.End),
+			})
+			if err != nil {
+				return err
+			}
+		case entities.Mention:
+			// link the mention to whoever has the handle right now, a
+			// handle nobody has is still stored so the text keeps its offsets
+			userID := uuid.NullUUID{}
+			user, err := q.GetUserByHandle(ctx, entity.Text)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if err == nil {
+				userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+			}
+
+			err = q.AddChirpMention(ctx, database.AddChirpMentionParams{
+				ChirpID:     chirpID,
+				Handle:      entity.Text,
+				UserID:      userID,
+				StartOffset: int32(entity.Start),
+				EndOffset:   int32(entity.End),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// get the entities for every chirp at once, keyed by chirp id
+func (cfg *apiConfig) getChirpEntities(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]ChirpEntities, error) {
+	hashtags, err := cfg.dbQueries.GetChirpHashtagEntities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := cfg.dbQueries.GetChirpMentionEntities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByChirp := make(map[uuid.UUID]ChirpEntities, len(ids))
+	for _, id := range ids {
+		entitiesByChirp[id] = ChirpEntities{
+			Hashtags: []HashtagEntity{},
+			Mentions: []MentionEntity{},
+		}
+	}
+
+	for _, hashtag := range hashtags {
+		chirpEntities := entitiesByChirp[hashtag.ChirpID]
+		chirpEntities.Hashtags = append(chirpEntities.Hashtags, HashtagEntity{
+			Tag:   hashtag.Tag,
+			Start: hashtag.StartOffset,
+			End:   hashtag.EndOffset,
+		})
+		entitiesByChirp[hashtag.ChirpID] = chirpEntities
+	}
+
+	for _, mention := range mentions {
+		chirpEntities := entitiesByChirp[mention.ChirpID]
+		returnMention := MentionEntity{
+			Handle: mention.Handle,
+			Start:  mention.StartOffset,
+			End:    mention.EndOffset,
+		}
+		if mention.UserID.Valid {
+			returnMention.UserID = &mention.UserID.UUID
+		}
+		chirpEntities.Mentions = append(chirpEntities.Mentions, returnMention)
+		entitiesByChirp[mention.ChirpID] = chirpEntities
+	}
+
+	return entitiesByChirp, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// permanently remove chirps whose restore window has passed, checking every
+// interval until ctx is done
+func (cfg *apiConfig) runChirpPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.purgeDeletedChirps(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// media.chirp_id is ON DELETE SET NULL, so the media of purged chirps is
+// removed here along with its files instead of being left behind
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) {
+	cutoff := time.Now().Add(-cfg.restoreWindow)
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Couldn't purge deleted chirps: %v", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	media, err := qtx.DeletePurgeableChirpMedia(ctx, cutoff)
+	if err != nil {
+		log.Printf("Couldn't purge media of deleted chirps: %v", err)
+		return
+	}
+
+	purged, err := qtx.PurgeDeletedChirps(ctx, cutoff)
+	if err != nil {
+		log.Printf("Couldn't purge deleted chirps: %v", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Couldn't purge deleted chirps: %v", err)
+		return
+	}
+
+	cfg.deleteMediaFiles(ctx, media)
+
+	if purged > 0 {
+		log.Printf("Purged %d deleted chirps and %d media files", purged, len(media))
+	}
+}
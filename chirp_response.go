@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+// build the response for a single chirp, see buildChirpResponses
+func (cfg *apiConfig) buildChirpResponse(ctx context.Context, viewerID uuid.NullUUID, chirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.buildChirpResponses(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// convert the database rows into the structs we send to clients, filling in
+// the per-chirp data that lives in other tables with one query per table
+func (cfg *apiConfig) buildChirpResponses(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]Chirp, error) {
+	return cfg.buildChirpResponseList(ctx, viewerID, chirps, true)
+}
+
+// rechirped and quoted chirps are only embedded one level deep, so a quote
+// of a quote shows the chirp it quotes without that chirp's own quote
+func (cfg *apiConfig) buildChirpResponseList(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp, embedReferences bool) ([]Chirp, error) {
+	returnChirps := make([]Chirp, 0, len(chirps))
+	if len(chirps) == 0 {
+		return returnChirps, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	// get the likes for every chirp at once
+	likeStats, err := cfg.dbQueries.GetChirpLikeStats(ctx, database.GetChirpLikeStatsParams{
+		ViewerID: viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	likesByChirp := make(map[uuid.UUID]database.GetChirpLikeStatsRow, len(likeStats))
+	for _, stat := range likeStats {
+		likesByChirp[stat.ChirpID] = stat
+	}
+
+	// get which of them the viewer bookmarked, only they can see that
+	bookmarkedIDs, err := cfg.dbQueries.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		ViewerID: viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+
+	// get which of them their authors have pinned
+	pinnedIDs, err := cfg.dbQueries.GetPinnedChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[uuid.UUID]bool, len(pinnedIDs))
+	for _, id := range pinnedIDs {
+		pinned[id.UUID] = true
+	}
+
+	// get the hashtags and mentions
+	entitiesByChirp, err := cfg.getChirpEntities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// get the attached media
+	media, err := cfg.dbQueries.GetChirpMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mediaByChirp := make(map[uuid.UUID][]ChirpMedia, len(chirps))
+	for _, medium := range media {
+		mediaByChirp[medium.ChirpID.UUID] = append(mediaByChirp[medium.ChirpID.UUID], cfg.databaseMediaToChirpMedia(medium))
+	}
+
+	// get the rechirped and quoted chirps
+	referencedByID := map[uuid.UUID]Chirp{}
+	if embedReferences {
+		referencedIDs := []uuid.UUID{}
+		for _, chirp := range chirps {
+			if chirp.ReferencedChirpID.Valid {
+				referencedIDs = append(referencedIDs, chirp.ReferencedChirpID.UUID)
+			}
+		}
+		if len(referencedIDs) > 0 {
+			referenced, err := cfg.dbQueries.GetChirpsByIDs(ctx, referencedIDs)
+			if err != nil {
+				return nil, err
+			}
+			returnReferenced, err := cfg.buildChirpResponseList(ctx, viewerID, referenced, false)
+			if err != nil {
+				return nil, err
+			}
+			for _, chirp := range returnReferenced {
+				referencedByID[chirp.ID] = chirp
+			}
+		}
+	}
+
+	for _, chirp := range chirps {
+		returnChirp := databaseChirpToChirp(chirp)
+		if referenced, ok := referencedByID[chirp.ReferencedChirpID.UUID]; ok && chirp.ReferencedChirpID.Valid {
+			returnChirp.ReferencedChirp = &referenced
+		}
+		returnChirp.LikeCount = likesByChirp[chirp.ID].LikeCount
+		returnChirp.LikedByMe = likesByChirp[chirp.ID].LikedByMe
+		returnChirp.BookmarkedByMe = bookmarked[chirp.ID]
+		returnChirp.Pinned = pinned[chirp.ID]
+		returnChirp.Entities = entitiesByChirp[chirp.ID]
+		returnChirp.Media = mediaByChirp[chirp.ID]
+		if returnChirp.Media == nil {
+			returnChirp.Media = []ChirpMedia{}
+		}
+		returnChirps = append(returnChirps, returnChirp)
+	}
+
+	return returnChirps, nil
+}
+
+// build the responses for a page of chirps fetched with one extra row and send it
+func (cfg *apiConfig) respondWithChirpPage(w http.ResponseWriter, r *http.Request, chirps []database.Chirp, limit int32) {
+	page := chirpPage{}
+	chirps, page.NextCursor = splitPage(chirps, limit, chirpCursorKey)
+
+	var err error
+	page.Chirps, err = cfg.buildChirpResponses(r.Context(), cfg.getViewerID(r), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// build the response for a single chirp as seen by the caller and send it
+func (cfg *apiConfig) respondWithChirp(w http.ResponseWriter, r *http.Request, code int, chirp database.Chirp) {
+	returnChirp, err := cfg.buildChirpResponse(r.Context(), cfg.getViewerID(r), chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, code, returnChirp)
+}
+
+// get the caller's id for endpoints that work without logging in, an absent
+// or invalid token just means an anonymous viewer
+func (cfg *apiConfig) getViewerID(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
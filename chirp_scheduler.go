@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+// publish scheduled chirps once their time comes, checking every interval
+// until ctx is done
+func (cfg *apiConfig) runChirpScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.publishDueChirps(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// the update only touches due chirps that are still scheduled, so running
+// more than one server can't publish the same chirp twice
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) {
+	now := time.Now()
+	published, err := cfg.dbQueries.PublishDueChirps(ctx, database.PublishDueChirpsParams{
+		PublishedAt: now,
+		Now: now,
+	})
+	if err != nil {
+		log.Printf("Couldn't publish scheduled chirps: %v", err)
+		return
+	}
+	if len(published) > 0 {
+		log.Printf("Published %d scheduled chirps", len(published))
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/moderation"
+	"github.com/rivo/uniseg"
+)
+
+// the most characters a chirp can have, chirpy red members get more
+type chirpLengthLimits struct {
+	Default   int `json:"default"`
+	ChirpyRed int `json:"chirpy_red"`
+}
+
+type chirpTooLongError struct {
+	Length    int
+	MaxLength int
+}
+
+func (e *chirpTooLongError) Error() string {
+	return fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", e.Length, e.MaxLength)
+}
+
+// a problem with a new chirp the user has to fix, Message is sent back
+type chirpRequestError struct {
+	Message string
+	Err     error
+}
+
+func (e *chirpRequestError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *chirpRequestError) Unwrap() error {
+	return e.Err
+}
+
+// the token is valid but the account it was issued for is gone
+var errChirpAuthorNotFound = errors.New("chirp author not found")
+
+// check a chirp body against the author's length limit and the moderation
+// filters, returning the cleaned body
+func (cfg *apiConfig) chirpsValidate(ctx context.Context, userID uuid.UUID, body string) (string, error) {
+	// get the author's tier
+	user, err := cfg.dbQueries.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errChirpAuthorNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't get chirp author: %w", err)
+	}
+	maxChirpLength := cfg.chirpLimits.Default
+	if user.IsChirpyRed {
+		maxChirpLength = cfg.chirpLimits.ChirpyRed
+	}
+
+	// count what the user sees as characters, not bytes
+	length := uniseg.GraphemeClusterCount(body)
+	if length > maxChirpLength {
+		return "", &chirpTooLongError{Length: length, MaxLength: maxChirpLength}
+	}
+
+	// run the body through the moderation filters
+	cleaned, err := cfg.moderator.Moderate(body)
+	if err != nil {
+		return "", err
+	}
+
+	return cleaned, nil
+}
+
+// respond with a 400 for chirps the user has to change, a 401 if their
+// account is gone, and a 500 otherwise
+func (cfg *apiConfig) respondWithValidationError(w http.ResponseWriter, err error) {
+	var tooLong *chirpTooLongError
+	if errors.As(err, &tooLong) {
+		type tooLongResponse struct {
+			Error     string            `json:"error"`
+			Length    int               `json:"length"`
+			MaxLength int               `json:"max_length"`
+			Limits    chirpLengthLimits `json:"limits"`
+		}
+		respondWithJSON(w, http.StatusBadRequest, tooLongResponse{
+			Error:     "Chirp is too long",
+			Length:    tooLong.Length,
+			MaxLength: tooLong.MaxLength,
+			Limits:    cfg.chirpLimits,
+		})
+		return
+	}
+
+	var rejected *moderation.RejectedError
+	if errors.As(err, &rejected) {
+		respondWithError(w, http.StatusBadRequest, "Chirp was rejected by moderation", err)
+		return
+	}
+
+	var requestErr *chirpRequestError
+	if errors.As(err, &requestErr) {
+		respondWithError(w, http.StatusBadRequest, requestErr.Message, err)
+		return
+	}
+
+	if errors.Is(err, errChirpAuthorNotFound) {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+
+	respondWithError(w, http.StatusInternalServerError, "Couldn't validate chirp", err)
+}
@@ -12,3 +12,5 @@ require github.com/joho/godotenv v1.5.1
 require golang.org/x/crypto v0.37.0
 
 require github.com/golang-jwt/jwt/v5 v5.2.2
+
+require github.com/rivo/uniseg v0.4.7
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/moderation"
+)
+
+type ModerationWord struct {
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (cfg *apiConfig) handlerListModerationWords(w http.ResponseWriter, r *http.Request) {
+	if !cfg.checkAdminKey(w, r) {
+		return
+	}
+
+	words, err := cfg.dbQueries.ListModerationWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't get moderation words", err)
+		return
+	}
+
+	returnWords := []ModerationWord{}
+	for _, word := range words {
+		returnWords = append(returnWords, databaseModerationWordToModerationWord(word))
+	}
+
+	respondWithJSON(w, http.StatusOK, returnWords)
+}
+
+func (cfg *apiConfig) handlerUpsertModerationWord(w http.ResponseWriter, r *http.Request) {
+	// request struct params
+	type parameters struct {
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+
+	if !cfg.checkAdminKey(w, r) {
+		return
+	}
+
+	// get the request
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "couldn't decode params", err)
+		return
+	}
+
+	// validate the word and action, defaulting to masking
+	err = moderation.ValidateWord(params.Word)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	action := moderation.ActionMask
+	if params.Action != "" {
+		action, err = moderation.ParseAction(params.Action)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	word, err := cfg.dbQueries.UpsertModerationWord(r.Context(), database.UpsertModerationWordParams{
+		Word: moderation.NormalizeWord(params.Word),
+		Action: string(action),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't store moderation word", err)
+		return
+	}
+
+	// apply the change right away
+	err = cfg.reloadModerationWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't reload moderation words", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseModerationWordToModerationWord(word))
+}
+
+func (cfg *apiConfig) handlerDeleteModerationWord(w http.ResponseWriter, r *http.Request) {
+	if !cfg.checkAdminKey(w, r) {
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteModerationWord(r.Context(), moderation.NormalizeWord(r.PathValue("word")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete moderation word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "moderation word not found", nil)
+		return
+	}
+
+	// apply the change right away
+	err = cfg.reloadModerationWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't reload moderation words", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// admin endpoints need the ADMIN_KEY as an ApiKey, and are turned off without one
+func (cfg *apiConfig) checkAdminKey(w http.ResponseWriter, r *http.Request) bool {
+	if cfg.adminKey == "" {
+		respondWithError(w, http.StatusForbidden, "admin api is disabled", fmt.Errorf("ADMIN_KEY isn't set"))
+		return false
+	}
+
+	key, err := auth.GetAPIKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get key", err)
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key), []byte(cfg.adminKey)) != 1 {
+		respondWithError(w, http.StatusUnauthorized, "invalid key", fmt.Errorf("key invalid"))
+		return false
+	}
+
+	return true
+}
+
+func databaseModerationWordToModerationWord(word database.ModerationWord) ModerationWord {
+	return ModerationWord{
+		Word: word.Word,
+		Action: word.Action,
+		CreatedAt: word.CreatedAt,
+		UpdatedAt: word.UpdatedAt,
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerChirpBookmark(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.getChirpTarget(w, r)
+	if !ok {
+		return
+	}
+
+	// bookmarking twice is a no-op thanks to the primary key
+	err := cfg.dbQueries.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID: userID,
+		ChirpID: chirpID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpUnbookmark(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the uuid of the chirp
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// unlike bookmarking this doesn't look the chirp up, so a bookmark on a
+	// deleted chirp can still be removed
+	err = cfg.dbQueries.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID: userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// newest bookmark first, with one extra so we know if there is a next page
+	rows, err := cfg.dbQueries.GetBookmarksPage(r.Context(), database.GetBookmarksPageParams{
+		UserID: userID,
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmarks", err)
+		return
+	}
+
+	// the cursor is the bookmark's time, not the chirp's
+	page := chirpPage{}
+	rows, page.NextCursor = splitPage(rows, pageParams.Limit, func(row database.GetBookmarksPageRow) (time.Time, uuid.UUID) {
+		return row.BookmarkedAt, row.Chirp.ID
+	})
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+
+	page.Chirps, err = cfg.buildChirpResponses(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
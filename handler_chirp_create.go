@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,23 +15,58 @@ import (
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	User_ID   uuid.UUID `json:"user_id"`
+	ID              uuid.UUID     `json:"id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Body            string        `json:"body"`
+	User_ID         uuid.UUID     `json:"user_id"`
+	InReplyTo       *uuid.UUID    `json:"in_reply_to"`
+	LikeCount       int64         `json:"like_count"`
+	LikedByMe       bool          `json:"liked_by_me"`
+	BookmarkedByMe  bool          `json:"bookmarked_by_me"`
+	Pinned          bool          `json:"pinned"`
+	Entities        ChirpEntities `json:"entities"`
+	Media           []ChirpMedia  `json:"media"`
+	ReferenceType   *string       `json:"reference_type"`
+	ReferencedChirp *Chirp        `json:"referenced_chirp"`
+	PublishAt       *time.Time    `json:"publish_at"`
 }
 
-
-func (cfg *apiConfig) handlerChirpCreate(w http.ResponseWriter, r *http.Request) {
-	// request struct params
-	type parameters struct {
-		Body string `json:"body"`
+// convert the database row into the struct we send to clients
+func databaseChirpToChirp(chirp database.Chirp) Chirp {
+	returnChirp := Chirp{
+		ID: chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body: chirp.Body,
+		User_ID: chirp.UserID,
+	}
+	if chirp.ParentID.Valid {
+		returnChirp.InReplyTo = &chirp.ParentID.UUID
+	}
+	if chirp.ReferenceType.Valid {
+		returnChirp.ReferenceType = &chirp.ReferenceType.String
 	}
+	if chirp.PublishAt.Valid {
+		returnChirp.PublishAt = &chirp.PublishAt.Time
+	}
+	return returnChirp
+}
+
+
+// what a client sends to create a chirp, drafts store the same fields
+type chirpParameters struct {
+	Body string `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	MediaIDs []uuid.UUID `json:"media_ids"`
+	QuoteChirpID *uuid.UUID `json:"quote_chirp_id"`
+	PublishAt *time.Time `json:"publish_at"`
+}
 
+func (cfg *apiConfig) handlerChirpCreate(w http.ResponseWriter, r *http.Request) {
 	// get request and decode it, handling errors
 	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
+	params := chirpParameters{}
 	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode params", err)
@@ -50,58 +87,147 @@ func (cfg *apiConfig) handlerChirpCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// validate the body
-	cleaned, err := chirpsValidate(params.Body)
+	if !cfg.requireVerifiedEmail(w, r, validatedID, actionChirp) {
+		return
+	}
+
+	// validate the body and everything it refers to
+	createParams, err := cfg.prepareChirp(r.Context(), validatedID, params)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Couldn't validate chirp", err)
+		cfg.respondWithValidationError(w, err)
 		return
 	}
 
 	// insert chirp into database
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		ID: uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Body: cleaned,
-		UserID: validatedID,
-	})
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	chirp, err := createChirp(r.Context(), cfg.dbQueries.WithTx(tx), createParams, params.MediaIDs)
 	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			respondWithError(w, http.StatusBadRequest, "Media not found or already attached to a chirp", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit chirp", err)
+		return
+	}
+
 	// respond with the nerly created chirp
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID: chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.CreatedAt,
-		Body: chirp.Body,
-		User_ID: chirp.UserID,
-	})
+	returnChirp, err := cfg.buildChirpResponse(r.Context(), uuid.NullUUID{UUID: validatedID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, returnChirp)
 }
 
-func chirpsValidate(body string) (string, error) {
-const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", fmt.Errorf("Chirp is too long")
+// validate a new chirp for userID and resolve the chirps it replies to or
+// quotes, returning the row to insert with createChirp
+func (cfg *apiConfig) prepareChirp(ctx context.Context, userID uuid.UUID, params chirpParameters) (database.CreateChirpParams, error) {
+	// validate the body
+	cleaned, err := cfg.chirpsValidate(ctx, userID, params.Body)
+	if err != nil {
+		return database.CreateChirpParams{}, err
+	}
+
+	if len(params.MediaIDs) > maxChirpMedia {
+		return database.CreateChirpParams{}, &chirpRequestError{
+			Message: "Too many media attachments",
+			Err: fmt.Errorf("chirp has %d media, the limit is %d", len(params.MediaIDs), maxChirpMedia),
+		}
+	}
+
+	// chirps with a publish time stay hidden until the scheduler publishes them
+	publishAt := sql.NullTime{}
+	if params.PublishAt != nil {
+		if !params.PublishAt.After(time.Now()) {
+			return database.CreateChirpParams{}, &chirpRequestError{
+				Message: "publish_at must be in the future",
+				Err: fmt.Errorf("publish_at %s has already passed", params.PublishAt),
+			}
+		}
+		publishAt = sql.NullTime{Time: *params.PublishAt, Valid: true}
+	}
+
+	// make sure the chirp we reply to exists
+	parentID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.dbQueries.GetChirps(ctx, *params.InReplyTo)
+		if err != nil {
+			return database.CreateChirpParams{}, &chirpRequestError{Message: "Couldn't find the chirp being replied to", Err: err}
+		}
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	// make sure the chirp we quote exists
+	referencedChirpID := uuid.NullUUID{}
+	referenceType := sql.NullString{}
+	if params.QuoteChirpID != nil {
+		quoted, err := cfg.getReferenceTarget(ctx, *params.QuoteChirpID)
+		if err != nil {
+			return database.CreateChirpParams{}, &chirpRequestError{Message: "Couldn't find the chirp being quoted", Err: err}
+		}
+		referencedChirpID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+		referenceType = sql.NullString{String: referenceTypeQuote, Valid: true}
 	}
-	cleaned := getCleanedBody(body, badWords)
 
-	return cleaned, nil
+	return database.CreateChirpParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Body: cleaned,
+		UserID: userID,
+		ParentID: parentID,
+		ReferencedChirpID: referencedChirpID,
+		ReferenceType: referenceType,
+		PublishAt: publishAt,
+	}, nil
 }
 
-func getCleanedBody(str string, badWords map[string]struct{}) string {
-	words := strings.Split(str, " ")
-	for i, word := range words {
-		if _, ok := badWords[strings.ToLower(word)]; ok {
-			words[i] = "****"
+var errInvalidMedia = errors.New("media not found or already attached")
+
+// insert a validated chirp along with everything parsed out of its body and
+// attach the author's uploaded media, q should be bound to a transaction so
+// a failure leaves nothing behind
+func createChirp(ctx context.Context, q *database.Queries, params database.CreateChirpParams, mediaIDs []uuid.UUID) (database.Chirp, error) {
+	chirp, err := q.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if len(mediaIDs) > 0 {
+		// only unattached media owned by the author can be used
+		unique := map[uuid.UUID]struct{}{}
+		for _, id := range mediaIDs {
+			unique[id] = struct{}{}
+		}
+		attached, err := q.AttachMediaToChirp(ctx, database.AttachMediaToChirpParams{
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			MediaIds: mediaIDs,
+			UserID: chirp.UserID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if attached != int64(len(unique)) {
+			return database.Chirp{}, errInvalidMedia
 		}
 	}
-	return strings.Join(words, " ")
+
+	err = storeChirpEntities(ctx, q, chirp.ID, chirp.Body)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, nil
 }
\ No newline at end of file
@@ -1,53 +1,106 @@
 package main
 
 import (
+	"database/sql"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
 )
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	// get the token
-	token, err := auth.GetBearerToken(r.Header)
+	// select the chirp, making sure it belongs to the caller
+	chirp, ok := cfg.getOwnedChirp(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
 
-	// validate token
-	userID, err := auth.ValidateJWT(token, cfg.secret)
+	// rechirps have nothing of their own so they go with the original and
+	// share its timestamp, that way a restore brings back the same ones
+	deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	err = qtx.SoftDeleteRechirpsOf(r.Context(), database.SoftDeleteRechirpsOfParams{
+		DeletedAt: deletedAt,
+		ReferencedChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	})
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete rechirps", err)
+		return
 	}
 
+	// mark the chirp as deleted, the purge job removes it for good once the
+	// restore window is over
+	err = qtx.SoftDeleteChirp(r.Context(), database.SoftDeleteChirpParams{
+		DeletedAt: deletedAt,
+		ID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit delete", err)
+		return
+	}
+
+	// respond if succesfull
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// validates the JWT and selects the chirp in the path, responding with an
+// error and returning false if the caller doesn't own it
+func (cfg *apiConfig) getOwnedChirp(w http.ResponseWriter, r *http.Request) (database.Chirp, bool) {
 	// get the uuid of the chirp
 	id, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
-		return
+		return database.Chirp{}, false
+	}
+
+	return cfg.getOwnedChirpByID(w, r, id)
+}
+
+// same as getOwnedChirp for endpoints that take the chirp id somewhere
+// other than the path
+func (cfg *apiConfig) getOwnedChirpByID(w http.ResponseWriter, r *http.Request, id uuid.UUID) (database.Chirp, bool) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return database.Chirp{}, false
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return database.Chirp{}, false
 	}
 
 	// select the chirp
 	chirp, err := cfg.dbQueries.GetChirps(r.Context(), id)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "chirp not found", err)
-		return
+		return database.Chirp{}, false
 	}
 
 	// validate owner of chirp
 	if chirp.UserID != userID {
-		respondWithError(w, http.StatusForbidden, "not autherized to delete chirp, it doesn't belong to you", err)
-		return
+		respondWithError(w, http.StatusForbidden, "not autherized to change chirp, it doesn't belong to you", fmt.Errorf("chirp %s isn't owned by user %s", chirp.ID, userID))
+		return database.Chirp{}, false
 	}
 
-	// delete chirp
-	err = cfg.dbQueries.DeleteChirpFromID(r.Context(), id)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "couldn't delete chirp", err)
-		return
-	}
-
-	// respond if succesfull
-	respondWithJSON(w, http.StatusNoContent, nil)
-}
\ No newline at end of file
+	return chirp, true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	// tags are stored lower case and without the #
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// get one extra chirp so we know if there is a next page
+	chirps, err := cfg.dbQueries.GetHashtagChirpsPage(r.Context(), database.GetHashtagChirpsPageParams{
+		Tag: tag,
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps for hashtag", err)
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, pageParams.Limit)
+}
+
+func (cfg *apiConfig) handlerUserMentions(w http.ResponseWriter, r *http.Request) {
+	// parse the id (string) into an id (uuid)
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// get one extra chirp so we know if there is a next page
+	chirps, err := cfg.dbQueries.GetUserMentionsPage(r.Context(), database.GetUserMentionsPageParams{
+		UserID: uuid.NullUUID{UUID: userID, Valid: true},
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions for user", err)
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, pageParams.Limit)
+}
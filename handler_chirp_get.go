@@ -1,63 +1,114 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
-	"sort"
 
 	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
 )
 
-func (cfg *apiConfig) handlerChirpSelectAll(w http.ResponseWriter, r *http.Request) {
-	// get all chirps, handle errors
-	chirps, err := cfg.dbQueries.GetAllChirps(r.Context())
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps from database", err)
-		return
-	}
+type chirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor *string `json:"next_cursor"`
+}
 
+func (cfg *apiConfig) handlerChirpSelectAll(w http.ResponseWriter, r *http.Request) {
 	// get the author id and parse it as an uuid if it isn't
-	authorID := uuid.Nil
+	authorID := uuid.NullUUID{}
 	authorIDString := r.URL.Query().Get("author_id")
 	if authorIDString != "" {
-		authorID, err = uuid.Parse(authorIDString)
+		id, err := uuid.Parse(authorIDString)
 		if err != nil {
 			respondWithError(w, http.StatusBadRequest, "Invalid auther ID", err)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
 	}
 
-	// store the sort direction
-	sortDirection := "asc"
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		sortDirection = "desc"
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
 	}
 
-
-	// construct a slice of structs for better control
-	returnChirp := []Chirp{}
-	for _, chirp := range chirps {
-		if authorID != uuid.Nil && chirp.UserID != authorID {
-			continue
+	// an author's pinned chirp goes on top of the first page of their
+	// chirps, and is left out of the rest so it doesn't show up twice
+	var pinned *database.Chirp
+	if authorID.Valid {
+		author, err := cfg.dbQueries.GetUserByID(r.Context(), authorID.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get author", err)
+			return
 		}
-		returnChirp = append(returnChirp, Chirp{
-			ID: chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body: chirp.Body,
-			User_ID: chirp.UserID,
+		pinned, err = cfg.getPinnedChirp(r.Context(), author)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get pinned chirp", err)
+			return
+		}
+	}
+	excludeID := uuid.NullUUID{}
+	if pinned != nil {
+		excludeID = uuid.NullUUID{UUID: pinned.ID, Valid: true}
+	}
+
+	// the pinned chirp takes one of the first page's slots
+	limit := pageParams.Limit
+	pinnedOnPage := pinned != nil && !pageParams.CursorID.Valid
+	if pinnedOnPage {
+		limit--
+	}
+	desc := r.URL.Query().Get("sort") == "desc"
+
+	// get one extra chirp so we know if there is a next page
+	var chirps []database.Chirp
+	if desc {
+		chirps, err = cfg.dbQueries.GetChirpsPageDesc(r.Context(), database.GetChirpsPageDescParams{
+			AuthorID: authorID,
+			ExcludeID: excludeID,
+			CursorCreatedAt: pageParams.CursorCreatedAt,
+			CursorID: pageParams.CursorID,
+			PageLimit: limit + 1,
+		})
+	} else {
+		chirps, err = cfg.dbQueries.GetChirpsPageAsc(r.Context(), database.GetChirpsPageAscParams{
+			AuthorID: authorID,
+			ExcludeID: excludeID,
+			CursorCreatedAt: pageParams.CursorCreatedAt,
+			CursorID: pageParams.CursorID,
+			PageLimit: limit + 1,
 		})
 	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps from database", err)
+		return
+	}
 
-	sort.Slice(returnChirp, func(i, j int) bool {
-		if sortDirection == "desc" {
-			return returnChirp[i].CreatedAt.After(returnChirp[j].CreatedAt)
+	// construct the page, the cursor points at the last chirp we return
+	page := chirpPage{}
+	if limit == 0 {
+		// only the pinned chirp fits, so the next page starts at the beginning
+		if len(chirps) > 0 {
+			cursor := startCursor(desc)
+			page.NextCursor = &cursor
 		}
-		return returnChirp[i].CreatedAt.Before(returnChirp[j].CreatedAt)
-	})
+		chirps = nil
+	} else {
+		chirps, page.NextCursor = splitPage(chirps, limit, chirpCursorKey)
+	}
+	if pinnedOnPage {
+		chirps = append([]database.Chirp{*pinned}, chirps...)
+	}
 
+	page.Chirps, err = cfg.buildChirpResponses(r.Context(), cfg.getViewerID(r), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
 
-	respondWithJSON(w, http.StatusOK, returnChirp)
+	respondWithJSON(w, http.StatusOK, page)
 }
 
 func (cfg *apiConfig) handlerChirpSelect(w http.ResponseWriter, r *http.Request) {
@@ -76,12 +127,5 @@ func (cfg *apiConfig) handlerChirpSelect(w http.ResponseWriter, r *http.Request)
 	}
 
 	// insert into custom struct for better control and send response
-	chirpStruct := Chirp{
-		ID: chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body: chirp.Body,
-		User_ID: chirp.UserID,
-	}
-	respondWithJSON(w, http.StatusOK, chirpStruct)
+	cfg.respondWithChirp(w, r, http.StatusOK, chirp)
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerChirpLike(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.getChirpTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, userID, actionLike) {
+		return
+	}
+
+	// liking twice is a no-op thanks to the unique constraint
+	err := cfg.dbQueries.LikeChirp(r.Context(), database.LikeChirpParams{
+		ChirpID: chirpID,
+		UserID: userID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't like chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpUnlike(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.getChirpTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.dbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		ChirpID: chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't unlike chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validates the JWT and makes sure the chirp in the path exists
+func (cfg *apiConfig) getChirpTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// get the uuid of the chirp
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// make sure the chirp exists
+	_, err = cfg.dbQueries.GetChirps(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, chirpID, true
+}
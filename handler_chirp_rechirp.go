@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/lib/pq"
+)
+
+const (
+	referenceTypeRechirp = "rechirp"
+	referenceTypeQuote   = "quote"
+
+	// postgres error code for a unique constraint violation
+	uniqueViolation = "23505"
+)
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	// get JWT token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, userID, actionChirp) {
+		return
+	}
+
+	// get the uuid of the chirp
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// find the original, rechirping a rechirp rechirps what it points at
+	original, err := cfg.getReferenceTarget(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return
+	}
+
+	// a rechirp is a chirp with no body of its own
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	chirp, err := createChirp(r.Context(), cfg.dbQueries.WithTx(tx), database.CreateChirpParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Body: "",
+		UserID: userID,
+		ReferencedChirpID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		ReferenceType: sql.NullString{String: referenceTypeRechirp, Valid: true},
+	}, nil)
+	if err != nil {
+		// a user can only rechirp a chirp once
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			respondWithError(w, http.StatusConflict, "you already rechirped this chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create rechirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit rechirp", err)
+		return
+	}
+
+	cfg.respondWithChirp(w, r, http.StatusCreated, chirp)
+}
+
+// get the chirp a rechirp or quote should point at, following a rechirp to
+// its original so references are never chained through rechirps
+func (cfg *apiConfig) getReferenceTarget(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.dbQueries.GetChirps(ctx, id)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if chirp.ReferenceType.String == referenceTypeRechirp && chirp.ReferencedChirpID.Valid {
+		return cfg.dbQueries.GetChirps(ctx, chirp.ReferencedChirpID.UUID)
+	}
+	return chirp, nil
+}
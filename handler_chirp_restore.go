@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/lib/pq"
+)
+
+func (cfg *apiConfig) handlerChirpRestore(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the uuid of the chirp
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// only deleted chirps can be restored
+	chirp, err := cfg.dbQueries.GetDeletedChirp(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "deleted chirp not found", err)
+		return
+	}
+
+	// validate owner of chirp
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "not autherized to restore chirp, it doesn't belong to you", fmt.Errorf("chirp %s isn't owned by user %s", chirp.ID, userID))
+		return
+	}
+
+	// the purge job may not have caught up yet, so check the window here too
+	if time.Since(chirp.DeletedAt.Time) > cfg.restoreWindow {
+		respondWithError(w, http.StatusGone, "the restore window for this chirp has passed", fmt.Errorf("chirp %s was deleted at %s", chirp.ID, chirp.DeletedAt.Time))
+		return
+	}
+
+	// a rechirp is only worth restoring if the original is still around
+	if chirp.ReferenceType.String == referenceTypeRechirp {
+		_, err = cfg.dbQueries.GetChirps(r.Context(), chirp.ReferencedChirpID.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "the rechirped chirp has been deleted", err)
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	restored, err := qtx.RestoreChirp(r.Context(), chirp.ID)
+	if err != nil {
+		// the user rechirped the same chirp again after deleting this one
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			respondWithError(w, http.StatusConflict, "you already rechirped this chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+
+	// bring back the rechirps that were deleted along with it
+	err = qtx.RestoreRechirpsOf(r.Context(), database.RestoreRechirpsOfParams{
+		ReferencedChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		DeletedAt: chirp.DeletedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't restore rechirps", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit restore", err)
+		return
+	}
+
+	cfg.respondWithChirp(w, r, http.StatusOK, restored)
+}
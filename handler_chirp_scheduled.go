@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// get the user's pending chirps, the next one to go out first, with one
+	// extra so we know if there is a next page
+	chirps, err := cfg.dbQueries.GetScheduledChirpsPage(r.Context(), database.GetScheduledChirpsPageParams{
+		UserID: userID,
+		CursorPublishAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get scheduled chirps", err)
+		return
+	}
+
+	page := chirpPage{}
+	chirps, page.NextCursor = splitPage(chirps, pageParams.Limit, scheduledChirpCursorKey)
+	page.Chirps, err = cfg.buildChirpResponses(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerDeleteScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the uuid of the chirp
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// nobody has seen a scheduled chirp yet, so there is nothing to restore
+	// and it can go for good
+	deleted, err := cfg.dbQueries.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID: id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete scheduled chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "scheduled chirp not found", fmt.Errorf("no scheduled chirp %s for user %s", id, userID))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scheduled chirps are paged by when they go out rather than when they were written
+func scheduledChirpCursorKey(chirp database.Chirp) (time.Time, uuid.UUID) {
+	return chirp.PublishAt.Time, chirp.ID
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerChirpSearch(w http.ResponseWriter, r *http.Request) {
+	// get the search terms
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing search query", fmt.Errorf("q is empty"))
+		return
+	}
+
+	// get the author id and parse it as an uuid if it isn't
+	authorID := uuid.NullUUID{}
+	authorIDString := r.URL.Query().Get("author_id")
+	if authorIDString != "" {
+		id, err := uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid auther ID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	// get the page size and where the previous page stopped
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	searchParams := database.SearchChirpsParams{
+		Query: query,
+		AuthorID: authorID,
+		PageLimit: limit + 1,
+	}
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		rank, createdAt, id, err := decodeSearchCursor(cursorString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		searchParams.CursorRank = sql.NullFloat64{Float64: float64(rank), Valid: true}
+		searchParams.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		searchParams.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	// get the matching chirps, best match first
+	results, err := cfg.dbQueries.SearchChirps(r.Context(), searchParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	// the extra row only tells us there is another page
+	page := chirpPage{}
+	if len(results) > int(limit) {
+		results = results[:limit]
+		last := results[len(results)-1]
+		cursor := encodeSearchCursor(last.Rank, last.Chirp.CreatedAt, last.Chirp.ID)
+		page.NextCursor = &cursor
+	}
+
+	chirps := make([]database.Chirp, 0, len(results))
+	for _, result := range results {
+		chirps = append(chirps, result.Chirp)
+	}
+
+	page.Chirps, err = cfg.buildChirpResponses(r.Context(), cfg.getViewerID(r), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// results are ordered by rank first, so the cursor has to carry it along
+// with the (created_at, id) position the other lists use
+func encodeSearchCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decode a cursor made by encodeSearchCursor
+func decodeSearchCursor(cursor string) (float32, time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return 0, time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return 0, time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return 0, time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return 0, time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	return float32(rank), createdAt, id, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type ChirpThreadNode struct {
+	Chirp
+	Replies []*ChirpThreadNode `json:"replies"`
+}
+
+func (cfg *apiConfig) handlerChirpThread(w http.ResponseWriter, r *http.Request) {
+	// parse the id (string) into an id (uuid)
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// select the chirp the thread is centered on
+	chirp, err := cfg.dbQueries.GetChirps(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+
+	// everything above the chirp, oldest (the root) first
+	ancestors, err := cfg.dbQueries.GetChirpAncestors(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp ancestors", err)
+		return
+	}
+
+	// everything below the chirp, at any depth
+	descendants, err := cfg.dbQueries.GetChirpDescendants(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp replies", err)
+		return
+	}
+
+	// build every chirp in the thread at once
+	all := append(append(ancestors, chirp), descendants...)
+	returnChirps, err := cfg.buildChirpResponses(r.Context(), cfg.getViewerID(r), all)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	returnAncestors := returnChirps[:len(ancestors)]
+	returnRoot := returnChirps[len(ancestors)]
+	returnDescendants := returnChirps[len(ancestors)+1:]
+
+	// respond with the ancestors and the reply tree
+	thread := struct {
+		Ancestors []Chirp `json:"ancestors"`
+		Chirp *ChirpThreadNode `json:"chirp"`
+	}{
+		Ancestors: returnAncestors,
+		Chirp: buildReplyTree(returnRoot, returnDescendants),
+	}
+	respondWithJSON(w, http.StatusOK, thread)
+}
+
+// nest the descendants under their parents, keeping each level oldest first
+func buildReplyTree(root Chirp, descendants []Chirp) *ChirpThreadNode {
+	nodes := map[uuid.UUID]*ChirpThreadNode{
+		root.ID: {Chirp: root, Replies: []*ChirpThreadNode{}},
+	}
+	for _, chirp := range descendants {
+		nodes[chirp.ID] = &ChirpThreadNode{Chirp: chirp, Replies: []*ChirpThreadNode{}}
+	}
+
+	// descendants are sorted by created_at, so appending keeps replies in order
+	for _, chirp := range descendants {
+		if chirp.InReplyTo == nil {
+			continue
+		}
+		parent, ok := nodes[*chirp.InReplyTo]
+		if !ok {
+			continue
+		}
+		parent.Replies = append(parent.Replies, nodes[chirp.ID])
+	}
+
+	return nodes[root.ID]
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+type ChirpRevision struct {
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) handlerChirpUpdate(w http.ResponseWriter, r *http.Request) {
+	// request struct params
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	// select the chirp, making sure it belongs to the caller
+	chirp, ok := cfg.getOwnedChirp(w, r)
+	if !ok {
+		return
+	}
+
+	// a rechirp has no body of its own to edit
+	if chirp.ReferenceType.String == referenceTypeRechirp {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be edited", fmt.Errorf("chirp %s is a rechirp", chirp.ID))
+		return
+	}
+
+	// get request and decode it, handling errors
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	// validate the body
+	cleaned, err := cfg.chirpsValidate(r.Context(), chirp.UserID, params.Body)
+	if err != nil {
+		cfg.respondWithValidationError(w, err)
+		return
+	}
+
+	// store the old body and the new one together
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// lock the row so concurrent edits don't lose a revision
+	current, err := qtx.GetChirpForUpdate(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return
+	}
+
+	// nothing to do if the body didn't change
+	if current.Body == cleaned {
+		cfg.respondWithChirp(w, r, http.StatusOK, current)
+		return
+	}
+
+	now := time.Now()
+	err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ID: uuid.New(),
+		ChirpID: current.ID,
+		Body: current.Body,
+		CreatedAt: current.UpdatedAt,
+		ReplacedAt: now,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store chirp revision", err)
+		return
+	}
+
+	updated, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		Body: cleaned,
+		UpdatedAt: now,
+		ID: current.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	// re-parse the hashtags and mentions from the new body
+	err = storeChirpEntities(r.Context(), qtx, updated.ID, updated.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp entities", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit chirp update", err)
+		return
+	}
+
+	// respond with the updated chirp
+	cfg.respondWithChirp(w, r, http.StatusOK, updated)
+}
+
+func (cfg *apiConfig) handlerChirpHistory(w http.ResponseWriter, r *http.Request) {
+	// parse the id (string) into an id (uuid)
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	// make sure the chirp exists
+	_, err = cfg.dbQueries.GetChirps(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+
+	// get the previous bodies, newest first
+	revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp history", err)
+		return
+	}
+
+	returnRevisions := []ChirpRevision{}
+	for _, revision := range revisions {
+		returnRevisions = append(returnRevisions, ChirpRevision{
+			Body: revision.Body,
+			CreatedAt: revision.CreatedAt,
+			ReplacedAt: revision.ReplacedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, returnRevisions)
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+type Draft struct {
+	ID           uuid.UUID   `json:"id"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Body         string      `json:"body"`
+	InReplyTo    *uuid.UUID  `json:"in_reply_to"`
+	QuoteChirpID *uuid.UUID  `json:"quote_chirp_id"`
+	MediaIDs     []uuid.UUID `json:"media_ids"`
+	PublishAt    *time.Time  `json:"publish_at"`
+}
+
+type draftPage struct {
+	Drafts     []Draft `json:"drafts"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// convert the database row into the struct we send to clients
+func databaseDraftToDraft(draft database.Draft) Draft {
+	returnDraft := Draft{
+		ID: draft.ID,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+		Body: draft.Body,
+		MediaIDs: draft.MediaIds,
+	}
+	if draft.InReplyTo.Valid {
+		returnDraft.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.QuoteChirpID.Valid {
+		returnDraft.QuoteChirpID = &draft.QuoteChirpID.UUID
+	}
+	if draft.PublishAt.Valid {
+		returnDraft.PublishAt = &draft.PublishAt.Time
+	}
+	return returnDraft
+}
+
+// the fields a draft keeps are the ones POST /api/chirps takes, converted to
+// what the database stores, nothing is checked until the draft is published
+type draftFields struct {
+	Body         string
+	InReplyTo    uuid.NullUUID
+	QuoteChirpID uuid.NullUUID
+	MediaIDs     []uuid.UUID
+	PublishAt    sql.NullTime
+}
+
+func chirpParametersToDraftFields(params chirpParameters) draftFields {
+	fields := draftFields{
+		Body: params.Body,
+		MediaIDs: params.MediaIDs,
+	}
+	if fields.MediaIDs == nil {
+		fields.MediaIDs = []uuid.UUID{}
+	}
+	if params.InReplyTo != nil {
+		fields.InReplyTo = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+	if params.QuoteChirpID != nil {
+		fields.QuoteChirpID = uuid.NullUUID{UUID: *params.QuoteChirpID, Valid: true}
+	}
+	if params.PublishAt != nil {
+		fields.PublishAt = sql.NullTime{Time: *params.PublishAt, Valid: true}
+	}
+	return fields
+}
+
+func draftToChirpParameters(draft database.Draft) chirpParameters {
+	params := chirpParameters{
+		Body: draft.Body,
+		MediaIDs: draft.MediaIds,
+	}
+	if draft.InReplyTo.Valid {
+		params.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.QuoteChirpID.Valid {
+		params.QuoteChirpID = &draft.QuoteChirpID.UUID
+	}
+	if draft.PublishAt.Valid {
+		params.PublishAt = &draft.PublishAt.Time
+	}
+	return params
+}
+
+func (cfg *apiConfig) handlerDraftCreate(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get request and decode it, handling errors
+	decoder := json.NewDecoder(r.Body)
+	params := chirpParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	fields := chirpParametersToDraftFields(params)
+	draft, err := cfg.dbQueries.CreateDraft(r.Context(), database.CreateDraftParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID: userID,
+		Body: fields.Body,
+		InReplyTo: fields.InReplyTo,
+		QuoteChirpID: fields.QuoteChirpID,
+		MediaIds: fields.MediaIDs,
+		PublishAt: fields.PublishAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseDraftToDraft(draft))
+}
+
+func (cfg *apiConfig) handlerDraftList(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// most recently edited first, with one extra so we know if there is a
+	// next page
+	drafts, err := cfg.dbQueries.GetDraftsPage(r.Context(), database.GetDraftsPageParams{
+		UserID: userID,
+		CursorUpdatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get drafts", err)
+		return
+	}
+
+	page := draftPage{
+		Drafts: []Draft{},
+	}
+	drafts, page.NextCursor = splitPage(drafts, pageParams.Limit, func(draft database.Draft) (time.Time, uuid.UUID) {
+		return draft.UpdatedAt, draft.ID
+	})
+	for _, draft := range drafts {
+		page.Drafts = append(page.Drafts, databaseDraftToDraft(draft))
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerDraftGet(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.getDraftTarget(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.dbQueries.GetDraft(r.Context(), database.GetDraftParams{
+		ID: draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "draft not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseDraftToDraft(draft))
+}
+
+func (cfg *apiConfig) handlerDraftUpdate(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.getDraftTarget(w, r)
+	if !ok {
+		return
+	}
+
+	// get request and decode it, handling errors
+	decoder := json.NewDecoder(r.Body)
+	params := chirpParameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	// the request replaces the whole draft
+	fields := chirpParametersToDraftFields(params)
+	draft, err := cfg.dbQueries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body: fields.Body,
+		InReplyTo: fields.InReplyTo,
+		QuoteChirpID: fields.QuoteChirpID,
+		MediaIds: fields.MediaIDs,
+		PublishAt: fields.PublishAt,
+		UpdatedAt: time.Now(),
+		ID: draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "draft not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseDraftToDraft(draft))
+}
+
+func (cfg *apiConfig) handlerDraftDelete(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.getDraftTarget(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID: draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete draft", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "draft not found", fmt.Errorf("no draft %s for user %s", draftID, userID))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerDraftPublish(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.getDraftTarget(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.dbQueries.GetDraft(r.Context(), database.GetDraftParams{
+		ID: draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "draft not found", err)
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, userID, actionChirp) {
+		return
+	}
+
+	// drafts go through the same checks as POST /api/chirps
+	params := draftToChirpParameters(draft)
+	createParams, err := cfg.prepareChirp(r.Context(), userID, params)
+	if err != nil {
+		cfg.respondWithValidationError(w, err)
+		return
+	}
+
+	// the draft is removed in the same transaction, so it turns into
+	// exactly one chirp or stays a draft
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	deleted, err := qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID: draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete draft", err)
+		return
+	}
+	if deleted == 0 {
+		// someone else published it first
+		respondWithError(w, http.StatusNotFound, "draft not found", fmt.Errorf("draft %s was already published", draftID))
+		return
+	}
+
+	chirp, err := createChirp(r.Context(), qtx, createParams, params.MediaIDs)
+	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			respondWithError(w, http.StatusBadRequest, "Media not found or already attached to a chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit chirp", err)
+		return
+	}
+
+	cfg.respondWithChirp(w, r, http.StatusCreated, chirp)
+}
+
+// validates the JWT and parses the draft id in the path, drafts are always
+// looked up together with the caller so other users' drafts are a 404
+func (cfg *apiConfig) getDraftTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// get the uuid of the draft
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Draft ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, draftID, true
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+type FollowUser struct {
+	ID          uuid.UUID `json:"id"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+type followPage struct {
+	Users      []FollowUser `json:"users"`
+	NextCursor *string      `json:"next_cursor"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.getFollowTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, followerID, actionFollow) {
+		return
+	}
+
+	// following twice is a no-op
+	err := cfg.dbQueries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't follow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.getFollowTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.dbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, pageParams, ok := cfg.getFollowListParams(w, r)
+	if !ok {
+		return
+	}
+
+	// get one extra user so we know if there is a next page
+	followers, err := cfg.dbQueries.GetFollowersPage(r.Context(), database.GetFollowersPageParams{
+		UserID: userID,
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't get followers", err)
+		return
+	}
+
+	page := followPage{
+		Users: []FollowUser{},
+	}
+	followers, page.NextCursor = splitPage(followers, pageParams.Limit, func(row database.GetFollowersPageRow) (time.Time, uuid.UUID) {
+		return row.FollowedAt, row.ID
+	})
+	for _, follower := range followers {
+		page.Users = append(page.Users, FollowUser{
+			ID: follower.ID,
+			IsChirpyRed: follower.IsChirpyRed,
+			FollowedAt: follower.FollowedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, pageParams, ok := cfg.getFollowListParams(w, r)
+	if !ok {
+		return
+	}
+
+	// get one extra user so we know if there is a next page
+	following, err := cfg.dbQueries.GetFollowingPage(r.Context(), database.GetFollowingPageParams{
+		UserID: userID,
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't get followed users", err)
+		return
+	}
+
+	page := followPage{
+		Users: []FollowUser{},
+	}
+	following, page.NextCursor = splitPage(following, pageParams.Limit, func(row database.GetFollowingPageRow) (time.Time, uuid.UUID) {
+		return row.FollowedAt, row.ID
+	})
+	for _, followee := range following {
+		page.Users = append(page.Users, FollowUser{
+			ID: followee.ID,
+			IsChirpyRed: followee.IsChirpyRed,
+			FollowedAt: followee.FollowedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// validates the JWT and makes sure the user in the path exists and isn't the caller
+func (cfg *apiConfig) getFollowTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// validate token
+	followerID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// get the uuid of the user to follow
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if followerID == followeeID {
+		respondWithError(w, http.StatusBadRequest, "you can't follow yourself", fmt.Errorf("user %s tried to follow themselves", followerID))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	// make sure the user exists
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return uuid.Nil, uuid.Nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return followerID, followeeID, true
+}
+
+// parses the user in the path and the page parameters
+func (cfg *apiConfig) getFollowListParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, pageParams, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, pageParams{}, false
+	}
+
+	params, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return uuid.Nil, pageParams{}, false
+	}
+
+	return userID, params, true
+}
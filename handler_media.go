@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/thumbnail"
+)
+
+const (
+	maxMediaBytes = 5 << 20
+	thumbnailSize = 320
+	maxChirpMedia = 4
+)
+
+// the types we accept, sniffed from the file rather than trusting the client
+var mediaExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ChirpMedia struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+}
+
+func (cfg *apiConfig) handlerMediaUpload(w http.ResponseWriter, r *http.Request) {
+	// get JWT token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, userID, actionMedia) {
+		return
+	}
+
+	// leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaBytes+(1<<20))
+	err = r.ParseMultipartForm(maxMediaBytes)
+	if err != nil {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Couldn't parse upload, files can be at most 5MB", err)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Missing file", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return
+	}
+	if len(data) > maxMediaBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large, files can be at most 5MB", fmt.Errorf("upload is over %d bytes", maxMediaBytes))
+		return
+	}
+
+	// check the type
+	contentType := http.DetectContentType(data)
+	extension, ok := mediaExtensions[contentType]
+	if !ok {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only jpeg, png and gif images are allowed", fmt.Errorf("upload has type %s", contentType))
+		return
+	}
+
+	// make the thumbnail, this also makes sure the image decodes
+	thumb, err := thumbnail.Make(data, contentType, thumbnailSize)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read image", err)
+		return
+	}
+
+	// store both files
+	id := uuid.New()
+	storageKey := id.String() + extension
+	thumbnailKey := id.String() + "_thumb" + mediaExtensions[thumb.ContentType]
+	err = cfg.storage.Put(r.Context(), storageKey, contentType, bytes.NewReader(data))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store file", err)
+		return
+	}
+	err = cfg.storage.Put(r.Context(), thumbnailKey, thumb.ContentType, bytes.NewReader(thumb.Data))
+	if err != nil {
+		cfg.storage.Delete(r.Context(), storageKey)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store thumbnail", err)
+		return
+	}
+
+	media, err := cfg.dbQueries.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID: id,
+		CreatedAt: time.Now(),
+		UserID: userID,
+		ContentType: contentType,
+		SizeBytes: int64(len(data)),
+		Width: int32(thumb.Width),
+		Height: int32(thumb.Height),
+		StorageKey: storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+	if err != nil {
+		cfg.storage.Delete(r.Context(), storageKey)
+		cfg.storage.Delete(r.Context(), thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cfg.databaseMediaToChirpMedia(media))
+}
+
+func (cfg *apiConfig) databaseMediaToChirpMedia(media database.Medium) ChirpMedia {
+	return ChirpMedia{
+		ID: media.ID,
+		URL: cfg.storage.URL(media.StorageKey),
+		ThumbnailURL: cfg.storage.URL(media.ThumbnailKey),
+		ContentType: media.ContentType,
+		SizeBytes: media.SizeBytes,
+		Width: media.Width,
+		Height: media.Height,
+	}
+}
+
+// remove the files behind media rows that are already deleted, a file left
+// behind only costs disk space so failures are logged
+func (cfg *apiConfig) deleteMediaFiles(ctx context.Context, media []database.Medium) {
+	for _, medium := range media {
+		for _, key := range []string{medium.StorageKey, medium.ThumbnailKey} {
+			err := cfg.storage.Delete(ctx, key)
+			if err != nil {
+				log.Printf("Couldn't delete media file %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+const passwordResetTTL = time.Hour
+
+// reset emails are limited per address so nobody can flood an inbox, and per
+// IP so nobody can send them to everyone
+var (
+	passwordResetEmailLimit = auth.LockoutPolicy{
+		FreeAttempts: 3,
+		BaseDelay: 15 * time.Minute,
+		MaxDelay: 24 * time.Hour,
+	}
+	passwordResetIPLimit = auth.LockoutPolicy{
+		FreeAttempts: 10,
+		BaseDelay: time.Minute,
+		MaxDelay: time.Hour,
+	}
+)
+
+func (cfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	// the limits are counted the same way for addresses without an account
+	email := strings.TrimSpace(params.Email)
+	lockedUntil, err := cfg.reserveAttempt(r.Context(), map[string]auth.LockoutPolicy{
+		resetEmailThrottleKey(email): passwordResetEmailLimit,
+		resetIPThrottleKey(cfg.clientIP(r)): passwordResetIPLimit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check password reset requests", err)
+		return
+	}
+	if !lockedUntil.IsZero() {
+		respondWithLockout(w, lockedUntil, "Too many password reset requests, try again later")
+		return
+	}
+
+	// the account is only looked up after responding, so neither the
+	// response nor how long it takes says whether the email has an account
+	go cfg.forgotPassword(email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (cfg *apiConfig) forgotPassword(email string) {
+	ctx := context.Background()
+	user, err := cfg.dbQueries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Couldn't look up user for password reset: %s", err)
+		}
+		return
+	}
+
+	err = cfg.sendPasswordResetEmail(ctx, user)
+	if err != nil {
+		log.Printf("Couldn't send password reset email to user %s: %s", user.ID, err)
+	}
+}
+
+func (cfg *apiConfig) sendPasswordResetEmail(ctx context.Context, user database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	// only the hash is stored, the token itself is only ever in the email
+	err = cfg.dbQueries.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID: user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password of your Chirpy account.\n\nTo choose a new password, send this token to POST /api/password/reset:\n\n%s\n\nThe token expires in %s and works once. If it wasn't you, you can ignore this email.\n", token, passwordResetTTL)
+	return cfg.sendMail(ctx, user.Email, "Reset your password", body)
+}
+
+func (cfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	if !cfg.checkPasswordPolicy(w, params.Password) {
+		return
+	}
+
+	hashedPass, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "coulnd't hash password", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// marking the token used and checking it happen in one statement, so two
+	// requests with the same token can't both get through
+	now := time.Now()
+	resetToken, err := qtx.ClaimPasswordResetToken(r.Context(), database.ClaimPasswordResetTokenParams{
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: now,
+		TokenHash: auth.HashToken(params.Token),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Invalid or expired token", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "couldn't check token", err)
+		return
+	}
+
+	// any other reset emails sent before this one stop working too
+	err = qtx.UsePasswordResetTokens(r.Context(), database.UsePasswordResetTokensParams{
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: now,
+		UserID: resetToken.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't expire reset tokens", err)
+		return
+	}
+
+	err = qtx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+		HashedPassword: sql.NullString{
+			String: hashedPass,
+			Valid: true,
+		},
+		UpdatedAt: now,
+		ID: resetToken.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't update password", err)
+		return
+	}
+
+	// whoever knew the old password shouldn't stay logged in
+	err = qtx.RevokeUserRefreshTokens(r.Context(), database.RevokeUserRefreshTokensParams{
+		RevokedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: now,
+		UserID: resetToken.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't revoke refresh tokens", err)
+		return
+	}
+
+	// failures with the old password shouldn't keep the new one locked out
+	err = qtx.ClearLoginThrottle(r.Context(), accountThrottleKey(resetToken.UserID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't reset login attempts", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit password reset", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerTimeline(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// get the page size and where to start
+	pageParams, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid page parameters", err)
+		return
+	}
+
+	// get chirps from everyone the user follows, newest first, with one
+	// extra so we know if there is a next page
+	chirps, err := cfg.dbQueries.GetTimelinePage(r.Context(), database.GetTimelinePageParams{
+		UserID: userID,
+		CursorCreatedAt: pageParams.CursorCreatedAt,
+		CursorID: pageParams.CursorID,
+		PageLimit: pageParams.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get timeline", err)
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, chirps, pageParams.Limit)
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/totp"
+)
+
+const (
+	totpIssuer = "Chirpy"
+	// how many time steps either side of now a code is accepted for
+	totpSkew = 1
+	recoveryCodeCount = 10
+
+	mfaChallengeTTL = 5 * time.Minute
+)
+
+// wrong second factors are counted per user, apart from the password
+// lockout, since a correct password clears that and hands out a new challenge
+var mfaLockout = auth.LockoutPolicy{
+	FreeAttempts: 5,
+	BaseDelay: time.Minute,
+	MaxDelay: 24 * time.Hour,
+}
+
+func (cfg *apiConfig) handlerTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.getPasswordConfirmedUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", fmt.Errorf("user %s enabled TOTP at %s", user.ID, user.TotpEnabledAt.Time))
+		return
+	}
+
+	// starting over replaces a secret that was never confirmed
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate secret", err)
+		return
+	}
+	updated, err := cfg.dbQueries.SetPendingTOTPSecret(r.Context(), database.SetPendingTOTPSecretParams{
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+		UpdatedAt: time.Now(),
+		ID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store secret", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", fmt.Errorf("user %s enabled TOTP while enrolling", user.ID))
+		return
+	}
+
+	account := user.Email
+	if user.Handle.Valid {
+		account = user.Handle.String
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Secret string `json:"secret"`
+		URI string `json:"otpauth_uri"`
+	}{
+		Secret: secret,
+		URI: totp.URI(secret, totpIssuer, account),
+	})
+}
+
+func (cfg *apiConfig) handlerTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if user.TotpEnabledAt.Valid || !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusConflict, "No two-factor enrollment in progress", fmt.Errorf("user %s has nothing to confirm", user.ID))
+		return
+	}
+
+	// a correct code shows the app was set up with the right secret
+	step, ok := totp.Validate(user.TotpSecret.String, params.Code, time.Now(), totpSkew)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid code", fmt.Errorf("wrong TOTP code for user %s", user.ID))
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// the secret has to be the one the code was checked against, in case
+	// enrollment was restarted in the meantime
+	enabled, err := qtx.EnableTOTP(r.Context(), database.EnableTOTPParams{
+		TotpEnabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+		TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		UpdatedAt: time.Now(),
+		ID: user.ID,
+		TotpSecret: user.TotpSecret,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+	if enabled == 0 {
+		respondWithError(w, http.StatusConflict, "No two-factor enrollment in progress", fmt.Errorf("enrollment for user %s changed while confirming", user.ID))
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(r.Context(), qtx, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit two-factor enrollment", err)
+		return
+	}
+
+	// the codes are only ever shown here
+	respondWithJSON(w, http.StatusOK, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	})
+}
+
+func (cfg *apiConfig) handlerTOTPDisable(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+		Code string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	if !cfg.confirmPassword(w, r, user, params.Password, "Incorrect password") {
+		return
+	}
+
+	// turning it off takes the second factor too, so the password alone
+	// can't undo it, an enrollment that was never confirmed has none yet
+	if user.TotpEnabledAt.Valid {
+		if !cfg.reserveMFAAttempt(w, r, user.ID) {
+			return
+		}
+		ok, err := cfg.checkSecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+			return
+		}
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "Invalid code", fmt.Errorf("wrong second factor for user %s", user.ID))
+			return
+		}
+		cfg.clearMFAAttempts(r, user.ID)
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	err = qtx.DisableTOTP(r.Context(), database.DisableTOTPParams{
+		UpdatedAt: time.Now(),
+		ID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+
+	err = qtx.DeleteRecoveryCodes(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete recovery codes", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit disabling two-factor authentication", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hands out a short-lived token in place of real credentials, it's only good
+// for POST /api/login/mfa
+func (cfg *apiConfig) respondWithMFAChallenge(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "cannot generate MFA token", err)
+		return
+	}
+
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+	err = cfg.dbQueries.CreateMFAChallenge(r.Context(), database.CreateMFAChallengeParams{
+		TokenHash: auth.HashToken(token),
+		CreatedAt: time.Now(),
+		UserID: user.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't store MFA challenge", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		MFARequired bool `json:"mfa_required"`
+		MFAToken string `json:"mfa_token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{
+		MFARequired: true,
+		MFAToken: token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken string `json:"mfa_token"`
+		Code string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	tokenHash := auth.HashToken(params.MFAToken)
+	// expired challenges are filtered out by the database, which compares
+	// expires_at in the same zone it was written in
+	challenge, err := cfg.dbQueries.GetMFAChallenge(r.Context(), database.GetMFAChallengeParams{
+		TokenHash: tokenHash,
+		Now: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), challenge.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", err)
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		// two-factor was turned off after the password was checked
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", fmt.Errorf("user %s no longer has TOTP enabled", user.ID))
+		return
+	}
+
+	if !cfg.reserveMFAAttempt(w, r, user.ID) {
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+		return
+	}
+	if !ok {
+		cfg.logLoginFailure(r, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, loginFailureBadMFACode)
+		respondWithError(w, http.StatusUnauthorized, "Invalid code", fmt.Errorf("wrong second factor for user %s", user.ID))
+		return
+	}
+
+	// the challenge is single use, if it's already gone someone else used it
+	deleted, err := cfg.dbQueries.DeleteMFAChallenge(r.Context(), tokenHash)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't use MFA challenge", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", fmt.Errorf("MFA challenge for user %s was already used", user.ID))
+		return
+	}
+
+	cfg.clearMFAAttempts(r, user.ID)
+	cfg.respondWithLogin(w, r, user)
+}
+
+// counts an attempt at a second factor against the user and the IP before
+// the code is checked, so codes can't be guessed by sending many at once,
+// responding with a 429 once there have been too many
+func (cfg *apiConfig) reserveMFAAttempt(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	lockedUntil, err := cfg.reserveAttempt(r.Context(), map[string]auth.LockoutPolicy{
+		mfaThrottleKey(userID): mfaLockout,
+		ipThrottleKey(cfg.clientIP(r)): ipLockout,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check MFA attempts", err)
+		return false
+	}
+	if !lockedUntil.IsZero() {
+		respondWithLockout(w, lockedUntil, "Too many wrong codes, try again later")
+		return false
+	}
+	return true
+}
+
+// a correct code starts the user's count over and gives the IP its attempt
+// back, failing to do that only means fewer tries next time
+func (cfg *apiConfig) clearMFAAttempts(r *http.Request, userID uuid.UUID) {
+	err := cfg.dbQueries.ClearLoginThrottle(r.Context(), mfaThrottleKey(userID))
+	if err == nil {
+		err = cfg.releaseAttempt(r.Context(), ipThrottleKey(cfg.clientIP(r)), ipLockout)
+	}
+	if err != nil {
+		log.Printf("Couldn't clear MFA attempts for user %s: %s", userID, err)
+	}
+}
+
+// a TOTP code is only accepted once, so a code someone saw over the user's
+// shoulder can't be replayed, and recovery codes are crossed off as used
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, user database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(user.TotpSecret.String, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		used, err := cfg.dbQueries.UseTOTPStep(ctx, database.UseTOTPStepParams{
+			Step: sql.NullInt64{Int64: step, Valid: true},
+			ID: user.ID,
+		})
+		if err != nil {
+			return false, err
+		}
+		return used == 1, nil
+	}
+
+	if recoveryCode != "" {
+		used, err := cfg.dbQueries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			UserID: user.ID,
+			CodeHash: auth.HashToken(totp.NormalizeRecoveryCode(recoveryCode)),
+		})
+		if err != nil {
+			return false, err
+		}
+		return used == 1, nil
+	}
+
+	return false, nil
+}
+
+// throws away the user's recovery codes and makes new ones, only the hashes
+// are stored
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userID uuid.UUID) ([]string, error) {
+	err := q.DeleteRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := totp.RecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		err = q.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			CodeHash: auth.HashToken(totp.NormalizeRecoveryCode(code)),
+			UserID: userID,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// validates the JWT and checks the password sent in the body, for changes
+// that a stolen access token alone shouldn't be enough for
+func (cfg *apiConfig) getPasswordConfirmedUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return database.User{}, false
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return database.User{}, false
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return database.User{}, false
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return database.User{}, false
+	}
+
+	if !cfg.confirmPassword(w, r, user, params.Password, "Incorrect password") {
+		return database.User{}, false
+	}
+
+	return user, true
+}
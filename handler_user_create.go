@@ -3,12 +3,16 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kyoukyuubi/chirpy/internal/auth"
 	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/handles"
+	"github.com/lib/pq"
 )
 
 type User struct {
@@ -16,13 +20,33 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Email     string    `json:"email"`
+	Handle    *string   `json:"handle"`
 	IsChirpyRed bool `json:"is_chirpy_red"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+}
+
+func databaseUserToUser(user database.User) User {
+	returnUser := User{
+		ID: user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Email: user.Email,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+	if user.Handle.Valid {
+		returnUser.Handle = &user.Handle.String
+	}
+	if user.EmailVerifiedAt.Valid {
+		returnUser.EmailVerifiedAt = &user.EmailVerifiedAt.Time
+	}
+	return returnUser
 }
 
 func (cfg *apiConfig) handlerAddUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email string
 		Password string
+		Handle string
 	}
 
 	// decode the response and handle errors
@@ -34,6 +58,16 @@ func (cfg *apiConfig) handlerAddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	email, err := validateEmail(params.Email)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid email address", err)
+		return
+	}
+
+	if !cfg.checkPasswordPolicy(w, params.Password) {
+		return
+	}
+
 	// has the password, handling the errors
 	hash, err := auth.HashPassword(params.Password)
 	if err != nil {
@@ -41,29 +75,55 @@ func (cfg *apiConfig) handlerAddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the handle is optional, it can be picked later from the profile
+	handle := sql.NullString{}
+	handleChangedAt := sql.NullTime{}
+	if params.Handle != "" {
+		normalized := handles.Normalize(params.Handle)
+		err = handles.Validate(normalized)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		handle = sql.NullString{String: normalized, Valid: true}
+		handleChangedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
 	// input the user into the database and handle errors
 	user, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{
 		ID: uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Email: params.Email,
+		Email: email,
 		HashedPassword: sql.NullString{
 			String: hash,
 			Valid: true,
 		},
+		Handle: handle,
+		HandleChangedAt: handleChangedAt,
 	})
 	if err != nil {
+		if isHandleTaken(err) {
+			respondWithError(w, http.StatusConflict, "That handle is already taken", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Couldn't insert user into database", err)
 		return
 	}
 
-	// respond to the POST request with the newly created user
-	userStruct := User{
-		ID: user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email: user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+	// the account exists either way, a failed email can be sent again with
+	// POST /api/users/verify/resend
+	err = cfg.sendVerificationEmail(r.Context(), user)
+	if err != nil {
+		log.Printf("Couldn't send verification email to user %s: %s", user.ID, err)
 	}
-	respondWithJSON(w, http.StatusCreated, userStruct)
-}
\ No newline at end of file
+
+	// respond to the POST request with the newly created user
+	respondWithJSON(w, http.StatusCreated, databaseUserToUser(user))
+}
+
+// check if an insert or update failed because someone else has the handle
+func isHandleTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "users_handle_idx"
+}
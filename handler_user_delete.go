@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+)
+
+func (cfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	// get the request
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// locking the user row holds off uploads until the delete is done, new
+	// media can't be added for a user that is locked or gone
+	user, err := qtx.GetUserForUpdate(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	// deleting is permanent, so the token isn't enough on its own
+	if !cfg.confirmPassword(w, r, user, params.Password, "Incorrect password") {
+		return
+	}
+
+	// the rows go with the user, but the uploaded files have to be removed
+	// from storage by hand afterwards
+	media, err := qtx.GetMediaByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return
+	}
+
+	// rechirps only point at a chirp, so other users' rechirps of this
+	// user's chirps would be left empty by ON DELETE SET NULL
+	err = qtx.DeleteRechirpsOfUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete rechirps", err)
+		return
+	}
+
+	// chirps, refresh tokens, likes, follows, drafts, bookmarks and media
+	// are all removed by ON DELETE CASCADE
+	err = qtx.DeleteUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't delete user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit user deletion", err)
+		return
+	}
+
+	cfg.deleteMediaFiles(r.Context(), media)
+
+	w.WriteHeader(http.StatusNoContent)
+}
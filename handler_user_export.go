@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+// everything a user made or set, including chirps that are deleted or still
+// scheduled. credentials and security records (password hash, TOTP secret,
+// tokens, login attempts) are left out on purpose
+type UserExport struct {
+	ExportedAt     time.Time        `json:"exported_at"`
+	User           exportUser       `json:"user"`
+	Chirps         []exportChirp    `json:"chirps"`
+	ChirpRevisions []exportRevision `json:"chirp_revisions"`
+	Drafts         []Draft          `json:"drafts"`
+	Media          []exportMedia    `json:"media"`
+	Likes          []exportLike     `json:"likes"`
+	Bookmarks      []exportLike     `json:"bookmarks"`
+	Following      []exportFollow   `json:"following"`
+	Followers      []exportFollow   `json:"followers"`
+}
+
+type exportUser struct {
+	ID              uuid.UUID  `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Email           string     `json:"email"`
+	Handle          *string    `json:"handle"`
+	DisplayName     string     `json:"display_name"`
+	Bio             string     `json:"bio"`
+	AvatarMediaID   *uuid.UUID `json:"avatar_media_id"`
+	IsChirpyRed     bool       `json:"is_chirpy_red"`
+	PinnedChirpID   *uuid.UUID `json:"pinned_chirp_id"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+}
+
+type exportChirp struct {
+	ID                uuid.UUID  `json:"id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Body              string     `json:"body"`
+	InReplyTo         *uuid.UUID `json:"in_reply_to"`
+	ReferenceType     *string    `json:"reference_type"`
+	ReferencedChirpID *uuid.UUID `json:"referenced_chirp_id"`
+	DeletedAt         *time.Time `json:"deleted_at"`
+	PublishAt         *time.Time `json:"publish_at"`
+}
+
+type exportRevision struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	ChirpRevision
+}
+
+// a chirp the user liked or bookmarked
+type exportLike struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportFollow struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportMedia struct {
+	ChirpMedia
+	CreatedAt time.Time  `json:"created_at"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+}
+
+func (cfg *apiConfig) handlerExportUser(w http.ResponseWriter, r *http.Request) {
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	chirps, err := cfg.dbQueries.GetAllChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
+		return
+	}
+
+	media, err := cfg.dbQueries.GetMediaByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+		return
+	}
+
+	revisions, err := cfg.dbQueries.GetRevisionsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp revisions", err)
+		return
+	}
+
+	drafts, err := cfg.dbQueries.GetDraftsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get drafts", err)
+		return
+	}
+
+	likes, err := cfg.dbQueries.GetLikesByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get likes", err)
+		return
+	}
+
+	bookmarks, err := cfg.dbQueries.GetBookmarksByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmarks", err)
+		return
+	}
+
+	follows, err := cfg.dbQueries.GetFollowsOfUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return
+	}
+
+	export := UserExport{
+		ExportedAt: time.Now(),
+		User: databaseUserToExportUser(user),
+		Chirps: []exportChirp{},
+		ChirpRevisions: []exportRevision{},
+		Drafts: []Draft{},
+		Media: []exportMedia{},
+		Likes: []exportLike{},
+		Bookmarks: []exportLike{},
+		Following: []exportFollow{},
+		Followers: []exportFollow{},
+	}
+	for _, chirp := range chirps {
+		export.Chirps = append(export.Chirps, databaseChirpToExportChirp(chirp))
+	}
+	for _, revision := range revisions {
+		export.ChirpRevisions = append(export.ChirpRevisions, exportRevision{
+			ChirpID: revision.ChirpID,
+			ChirpRevision: ChirpRevision{
+				Body: revision.Body,
+				CreatedAt: revision.CreatedAt,
+				ReplacedAt: revision.ReplacedAt,
+			},
+		})
+	}
+	for _, draft := range drafts {
+		export.Drafts = append(export.Drafts, databaseDraftToDraft(draft))
+	}
+	for _, medium := range media {
+		exported := exportMedia{
+			ChirpMedia: cfg.databaseMediaToChirpMedia(medium),
+			CreatedAt: medium.CreatedAt,
+		}
+		if medium.ChirpID.Valid {
+			exported.ChirpID = &medium.ChirpID.UUID
+		}
+		export.Media = append(export.Media, exported)
+	}
+	for _, like := range likes {
+		export.Likes = append(export.Likes, exportLike{ChirpID: like.ChirpID, CreatedAt: like.CreatedAt})
+	}
+	for _, bookmark := range bookmarks {
+		export.Bookmarks = append(export.Bookmarks, exportLike{ChirpID: bookmark.ChirpID, CreatedAt: bookmark.CreatedAt})
+	}
+	// the same rows hold both sides, so sort them by which side the user is on
+	for _, follow := range follows {
+		if follow.FollowerID == user.ID {
+			export.Following = append(export.Following, exportFollow{UserID: follow.FolloweeID, CreatedAt: follow.CreatedAt})
+		} else {
+			export.Followers = append(export.Followers, exportFollow{UserID: follow.FollowerID, CreatedAt: follow.CreatedAt})
+		}
+	}
+
+	// make browsers save it as a file instead of showing it
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.json"`, user.ID))
+	respondWithJSON(w, http.StatusOK, export)
+}
+
+func databaseUserToExportUser(user database.User) exportUser {
+	exported := exportUser{
+		ID: user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Email: user.Email,
+		DisplayName: user.DisplayName,
+		Bio: user.Bio,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+	if user.Handle.Valid {
+		exported.Handle = &user.Handle.String
+	}
+	if user.AvatarMediaID.Valid {
+		exported.AvatarMediaID = &user.AvatarMediaID.UUID
+	}
+	if user.PinnedChirpID.Valid {
+		exported.PinnedChirpID = &user.PinnedChirpID.UUID
+	}
+	if user.EmailVerifiedAt.Valid {
+		exported.EmailVerifiedAt = &user.EmailVerifiedAt.Time
+	}
+	if user.TotpEnabledAt.Valid {
+		exported.TOTPEnabledAt = &user.TotpEnabledAt.Time
+	}
+	return exported
+}
+
+func databaseChirpToExportChirp(chirp database.Chirp) exportChirp {
+	exported := exportChirp{
+		ID: chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body: chirp.Body,
+	}
+	if chirp.ParentID.Valid {
+		exported.InReplyTo = &chirp.ParentID.UUID
+	}
+	if chirp.ReferenceType.Valid {
+		exported.ReferenceType = &chirp.ReferenceType.String
+	}
+	if chirp.ReferencedChirpID.Valid {
+		exported.ReferencedChirpID = &chirp.ReferencedChirpID.UUID
+	}
+	if chirp.DeletedAt.Valid {
+		exported.DeletedAt = &chirp.DeletedAt.Time
+	}
+	if chirp.PublishAt.Valid {
+		exported.PublishAt = &chirp.PublishAt.Time
+	}
+	return exported
+}
@@ -3,17 +3,20 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kyoukyuubi/chirpy/internal/auth"
 	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/handles"
 )
 
 func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email string
+		Handle string
 		Password string
 	}
 
@@ -26,20 +29,67 @@ func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// select from the database, handle the errors
-	user, err := cfg.dbQueries.GetUserByEmail(r.Context(), params.Email)
-	if err != nil {
+	// select from the database by email, or by handle if that's what was
+	// sent, handle the errors
+	var user database.User
+	identifier := params.Email
+	if params.Email == "" && params.Handle != "" {
+		identifier = handles.Normalize(params.Handle)
+		user, err = cfg.dbQueries.GetUserByHandle(r.Context(), identifier)
+	} else {
+		user, err = cfg.dbQueries.GetUserByEmail(r.Context(), params.Email)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't get user from database", err)
 		return
 	}
+	userID := uuid.NullUUID{UUID: user.ID, Valid: err == nil}
+
+	// the attempt is counted as a failure before the password is checked, so
+	// many requests at once can't all get in under the limit, a correct
+	// password gives it back
+	if !cfg.reserveLoginAttempt(w, r, userID) {
+		return
+	}
+	if !userID.Valid {
+		cfg.logLoginFailure(r, identifier, userID, loginFailureUnknownAccount)
+		respondWithError(w, http.StatusUnauthorized, "invalid login", err)
+		return
+	}
 
 	// check if the password matches
 	err = auth.CheckPasswordHash(user.HashedPassword.String, params.Password)
 	if err != nil {
+		cfg.logLoginFailure(r, identifier, userID, loginFailureBadPassword)
 		respondWithError(w, http.StatusUnauthorized, "invalid login", err)
 		return
 	}
 
+	// the account starts with a clean slate, wrong second factors are counted
+	// separately so this doesn't let codes be guessed forever
+	err = cfg.dbQueries.ClearLoginThrottle(r.Context(), accountThrottleKey(user.ID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't reset login attempts", err)
+		return
+	}
+	err = cfg.releaseAttempt(r.Context(), ipThrottleKey(cfg.clientIP(r)), ipLockout)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't reset login attempts", err)
+		return
+	}
+
+	// with two-factor on, the password only gets a challenge that has to be
+	// exchanged for tokens with a code at POST /api/login/mfa
+	if user.TotpEnabledAt.Valid {
+		cfg.respondWithMFAChallenge(w, r, user)
+		return
+	}
+
+	cfg.respondWithLogin(w, r, user)
+}
+
+// make the access and refresh tokens for a user who has proven who they are
+func (cfg *apiConfig) respondWithLogin(w http.ResponseWriter, r *http.Request, user database.User) {
 	// generate a JWT token
 	token, err := auth.MakeJWT(user.ID, cfg.secret, time.Hour)
 	if err != nil {
@@ -72,19 +122,11 @@ func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 
 	// respond with the user on success
 	userStruct := struct {
-		ID uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Email string `json:"email"`
-		IsChirpyRed bool `json:"is_chirpy_red"`
+		User
 		Token string `json:"token"`
 		RefreshToken string `json:"refresh_token"`
 	}{
-		ID: user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email: user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+		User: databaseUserToUser(user),
 		Token: token,
 		RefreshToken: refreshToken,
 	}
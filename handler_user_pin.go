@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	// request struct params
+	type parameters struct {
+		ChirpID uuid.UUID `json:"chirp_id"`
+	}
+
+	// get the request
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	// users can only pin their own chirps
+	chirp, ok := cfg.getOwnedChirpByID(w, r, params.ChirpID)
+	if !ok {
+		return
+	}
+
+	// a rechirp has nothing of the user's own to show off
+	if chirp.ReferenceType.String == referenceTypeRechirp {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be pinned", fmt.Errorf("chirp %s is a rechirp", chirp.ID))
+		return
+	}
+
+	// pinning replaces whatever was pinned before
+	err = cfg.dbQueries.SetPinnedChirp(r.Context(), database.SetPinnedChirpParams{
+		PinnedChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		UpdatedAt: time.Now(),
+		ID: chirp.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't pin chirp", err)
+		return
+	}
+
+	cfg.respondWithChirp(w, r, http.StatusOK, chirp)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	// get the token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "couldn't get token", err)
+		return
+	}
+
+	// validate token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		return
+	}
+
+	// unpinning with nothing pinned is a no-op
+	err = cfg.dbQueries.SetPinnedChirp(r.Context(), database.SetPinnedChirpParams{
+		PinnedChirpID: uuid.NullUUID{},
+		UpdatedAt: time.Now(),
+		ID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't unpin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// get the chirp a user has pinned, if they have one and it's still visible
+func (cfg *apiConfig) getPinnedChirp(ctx context.Context, user database.User) (*database.Chirp, error) {
+	if !user.PinnedChirpID.Valid {
+		return nil, nil
+	}
+
+	// deleted chirps keep their pin so it comes back if they're restored
+	chirp, err := cfg.dbQueries.GetChirps(ctx, user.PinnedChirpID.UUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chirp, nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/handles"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+
+	handleChangeCooldown = 30 * 24 * time.Hour
+)
+
+// what anyone can see about a user, unlike User it never has the email
+type UserProfile struct {
+	ID             uuid.UUID   `json:"id"`
+	Handle         *string     `json:"handle"`
+	DisplayName    string      `json:"display_name"`
+	Bio            string      `json:"bio"`
+	Avatar         *ChirpMedia `json:"avatar"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ChirpCount     int64       `json:"chirp_count"`
+	FollowerCount  int64       `json:"follower_count"`
+	FollowingCount int64       `json:"following_count"`
+	IsChirpyRed    bool        `json:"is_chirpy_red"`
+	PinnedChirp    *Chirp      `json:"pinned_chirp"`
+}
+
+func (cfg *apiConfig) handlerUserProfile(w http.ResponseWriter, r *http.Request) {
+	// get the uuid of the user
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	cfg.respondWithUserProfile(w, r, user)
+}
+
+func (cfg *apiConfig) handlerUserProfileByHandle(w http.ResponseWriter, r *http.Request) {
+	// handles are case-insensitive, the query takes care of that
+	user, err := cfg.dbQueries.GetUserByHandle(r.Context(), handles.Normalize(r.PathValue("handle")))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	cfg.respondWithUserProfile(w, r, user)
+}
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	// request struct params, fields left out stay as they are
+	type parameters struct {
+		Handle        optional[string]    `json:"handle"`
+		DisplayName   optional[string]    `json:"display_name"`
+		Bio           optional[string]    `json:"bio"`
+		AvatarMediaID optional[uuid.UUID] `json:"avatar_media_id"`
+	}
+
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	// get the request
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	// start from the current profile and apply what was sent
+	update := database.UpdateUserProfileParams{
+		DisplayName: user.DisplayName,
+		Bio: user.Bio,
+		AvatarMediaID: user.AvatarMediaID,
+		Handle: user.Handle,
+		HandleChangedAt: user.HandleChangedAt,
+		UpdatedAt: time.Now(),
+		ID: user.ID,
+	}
+
+	if params.DisplayName.Set {
+		update.DisplayName = ""
+		if params.DisplayName.Value != nil {
+			update.DisplayName = strings.TrimSpace(*params.DisplayName.Value)
+		}
+		if length := uniseg.GraphemeClusterCount(update.DisplayName); length > maxDisplayNameLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Display name can be at most %d characters", maxDisplayNameLength), fmt.Errorf("display name has %d characters", length))
+			return
+		}
+	}
+
+	if params.Bio.Set {
+		update.Bio = ""
+		if params.Bio.Value != nil {
+			update.Bio = strings.TrimSpace(*params.Bio.Value)
+		}
+		if length := uniseg.GraphemeClusterCount(update.Bio); length > maxBioLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Bio can be at most %d characters", maxBioLength), fmt.Errorf("bio has %d characters", length))
+			return
+		}
+	}
+
+	// a handle can't be removed once set, and only changed every so often
+	// so people can't hop between handles to confuse their followers
+	if params.Handle.Set {
+		if params.Handle.Value == nil {
+			respondWithError(w, http.StatusBadRequest, "Handle can't be removed", errors.New("handle set to null"))
+			return
+		}
+		handle := handles.Normalize(*params.Handle.Value)
+		err = handles.Validate(handle)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		if handle != user.Handle.String {
+			if user.HandleChangedAt.Valid && time.Since(user.HandleChangedAt.Time) < handleChangeCooldown {
+				nextChange := user.HandleChangedAt.Time.Add(handleChangeCooldown)
+				respondWithError(w, http.StatusTooManyRequests, fmt.Sprintf("Handle can't be changed again until %s", nextChange.UTC().Format(time.RFC3339)), fmt.Errorf("handle changed at %s", user.HandleChangedAt.Time))
+				return
+			}
+			update.Handle = sql.NullString{String: handle, Valid: true}
+			update.HandleChangedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
+
+	// the avatar is an image the user uploaded through POST /api/media
+	if params.AvatarMediaID.Set {
+		update.AvatarMediaID = uuid.NullUUID{}
+		if params.AvatarMediaID.Value != nil {
+			medium, err := cfg.dbQueries.GetMediaByID(r.Context(), *params.AvatarMediaID.Value)
+			if err != nil || medium.UserID != user.ID {
+				respondWithError(w, http.StatusBadRequest, "Avatar media not found", err)
+				return
+			}
+			update.AvatarMediaID = uuid.NullUUID{UUID: medium.ID, Valid: true}
+		}
+	}
+
+	user, err = cfg.dbQueries.UpdateUserProfile(r.Context(), update)
+	if err != nil {
+		if isHandleTaken(err) {
+			respondWithError(w, http.StatusConflict, "That handle is already taken", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "couldn't update profile", err)
+		return
+	}
+
+	cfg.respondWithUserProfile(w, r, user)
+}
+
+// build the public profile for a user and send it
+func (cfg *apiConfig) respondWithUserProfile(w http.ResponseWriter, r *http.Request, user database.User) {
+	profile, err := cfg.buildUserProfile(r.Context(), cfg.getViewerID(r), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build profile", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func (cfg *apiConfig) buildUserProfile(ctx context.Context, viewerID uuid.NullUUID, user database.User) (UserProfile, error) {
+	profile := UserProfile{
+		ID: user.ID,
+		DisplayName: user.DisplayName,
+		Bio: user.Bio,
+		CreatedAt: user.CreatedAt,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+	if user.Handle.Valid {
+		profile.Handle = &user.Handle.String
+	}
+
+	stats, err := cfg.dbQueries.GetUserProfileStats(ctx, user.ID)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	profile.ChirpCount = stats.ChirpCount
+	profile.FollowerCount = stats.FollowerCount
+	profile.FollowingCount = stats.FollowingCount
+
+	if user.AvatarMediaID.Valid {
+		medium, err := cfg.dbQueries.GetMediaByID(ctx, user.AvatarMediaID.UUID)
+		if err != nil {
+			return UserProfile{}, err
+		}
+		avatar := cfg.databaseMediaToChirpMedia(medium)
+		profile.Avatar = &avatar
+	}
+
+	pinned, err := cfg.getPinnedChirp(ctx, user)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	if pinned != nil {
+		returnChirp, err := cfg.buildChirpResponse(ctx, viewerID, *pinned)
+		if err != nil {
+			return UserProfile{}, err
+		}
+		profile.PinnedChirp = &returnChirp
+	}
+
+	return profile, nil
+}
@@ -3,19 +3,22 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/kyoukyuubi/chirpy/internal/auth"
 	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/lib/pq"
 )
 
 func (cfg *apiConfig) handlerUpdateUser (w http.ResponseWriter, r *http.Request) {
-	// request struct params
+	// request struct params, fields left out stay as they are
 	type parameters struct {
-		Email string `json:"email"`
-		Password string `json:"password"`
+		Email *string `json:"email"`
+		Password *string `json:"password"`
+		CurrentPassword string `json:"current_password"`
 	}
 
 	// get the access token
@@ -37,47 +40,114 @@ func (cfg *apiConfig) handlerUpdateUser (w http.ResponseWriter, r *http.Request)
 	params := parameters{}
 	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode params", err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode params", err)
 		return
 	}
 
-	// has the password
-	hashedPass, err := auth.HashPassword(params.Password)
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "coulnd't hash password", err)
+		respondWithError(w, http.StatusNotFound, "user not found", err)
 		return
 	}
 
-	// update the user
-	user, err := cfg.dbQueries.UpdateUserWithID(r.Context(), database.UpdateUserWithIDParams{
-		Email: params.Email,
-		HashedPassword: sql.NullString{
+	// start from the current credentials and apply what was sent
+	update := database.UpdateUserWithIDParams{
+		Email: user.Email,
+		HashedPassword: user.HashedPassword,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		UpdatedAt: time.Now(),
+		ID: user.ID,
+	}
+
+	emailChanged := false
+	if params.Email != nil {
+		email, err := validateEmail(*params.Email)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid email address", err)
+			return
+		}
+		emailChanged = email != user.Email
+		update.Email = email
+	}
+
+	// a new address has to be verified again
+	if emailChanged {
+		update.EmailVerifiedAt = sql.NullTime{}
+	}
+
+	passwordChanged := false
+	if params.Password != nil {
+		if !cfg.checkPasswordPolicy(w, *params.Password) {
+			return
+		}
+		passwordChanged = true
+	}
+
+	// a stolen access token alone shouldn't be enough to take over the account
+	if emailChanged || passwordChanged {
+		if !cfg.confirmPassword(w, r, user, params.CurrentPassword, "Current password is incorrect") {
+			return
+		}
+	}
+
+	if passwordChanged {
+		hashedPass, err := auth.HashPassword(*params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "coulnd't hash password", err)
+			return
+		}
+		update.HashedPassword = sql.NullString{
 			String: hashedPass,
 			Valid: true,
-		},
-		UpdatedAt: time.Now(),
-		ID: userID,
-	})
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// update the user
+	user, err = qtx.UpdateUserWithID(r.Context(), update)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			respondWithError(w, http.StatusConflict, "That email is already in use", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "couldn't update user", err)
 		return
 	}
 
-	// Make return struct
-	updatedUser := struct {
-		User_id uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Email string `json:"email"`
-		IsChirpyRed bool `json:"is_chirpy_red"`
-	}{
-		User_id: user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email: user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+	// log out every session that was started with the old password
+	if passwordChanged {
+		err = qtx.RevokeUserRefreshTokens(r.Context(), database.RevokeUserRefreshTokensParams{
+			RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			UpdatedAt: time.Now(),
+			UserID: user.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "couldn't revoke refresh tokens", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't commit user update", err)
+		return
+	}
+
+	if emailChanged {
+		err = cfg.sendVerificationEmail(r.Context(), user)
+		if err != nil {
+			log.Printf("Couldn't send verification email to user %s: %s", user.ID, err)
+		}
 	}
 
 	// respond with the new user minus pass
-	respondWithJSON(w, http.StatusOK, updatedUser)
-}
\ No newline at end of file
+	respondWithJSON(w, http.StatusOK, databaseUserToUser(user))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// what unverified users can be stopped from doing, set with
+// UNVERIFIED_RESTRICTIONS as a comma separated list
+const (
+	// posting chirps, rechirps and publishing drafts
+	actionChirp = "chirp"
+	actionLike = "like"
+	actionFollow = "follow"
+	actionMedia = "media"
+)
+
+var unverifiedActions = []string{actionChirp, actionLike, actionFollow, actionMedia}
+
+// "all" restricts everything, an empty value restricts nothing
+func parseUnverifiedRestrictions(value string) (map[string]bool, error) {
+	restrictions := map[string]bool{}
+	for _, action := range strings.Split(value, ",") {
+		action = strings.ToLower(strings.TrimSpace(action))
+		switch {
+		case action == "":
+			continue
+		case action == "all":
+			for _, known := range unverifiedActions {
+				restrictions[known] = true
+			}
+		case slices.Contains(unverifiedActions, action):
+			restrictions[action] = true
+		default:
+			return nil, fmt.Errorf("unknown action %q, expected one of %s or all", action, strings.Join(unverifiedActions, ", "))
+		}
+	}
+	return restrictions, nil
+}
+
+// responds with a 403 and returns false if the user hasn't verified their
+// email and unverified users aren't allowed to do action
+func (cfg *apiConfig) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, userID uuid.UUID, action string) bool {
+	if !cfg.unverifiedRestrictions[action] {
+		return true
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Verify your email address first", fmt.Errorf("user %s is unverified, can't %s", userID, action))
+		return false
+	}
+	return true
+}
+
+// checks that email is a bare address like user@example.com and returns it
+// without surrounding spaces
+func validateEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", err
+	}
+	// ParseAddress also takes "Name <user@example.com>"
+	if address.Address != email {
+		return "", fmt.Errorf("%q is not a bare email address", email)
+	}
+	// a domain without a dot can't receive mail from the internet
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("%q has no valid domain", email)
+	}
+	return email, nil
+}
+
+// replaces any earlier token for the user and mails a new one to their
+// current address
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, user database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.dbQueries.DeleteEmailVerificationTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.dbQueries.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		CreatedAt: time.Now(),
+		UserID: user.ID,
+		Email: user.Email,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm your email address for Chirpy by opening this link:\n\n%s\n\nThe link expires in %s. If you didn't sign up, you can ignore this email.\n", cfg.tokenLink("/api/users/verify", token), emailVerificationTTL)
+	return cfg.sendMail(ctx, user.Email, "Confirm your email address", body)
+}
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing token", errors.New("no token in query"))
+		return
+	}
+
+	// tokens are removed as they are used, so each one works once, and the
+	// expiry is checked by the database in the zone it was stored in
+	verification, err := cfg.dbQueries.ConsumeEmailVerificationToken(r.Context(), database.ConsumeEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		Now: time.Now(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired token", err)
+		return
+	}
+
+	// the token is only good for the address it was sent to
+	user, err := cfg.dbQueries.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		EmailVerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		UpdatedAt: time.Now(),
+		ID: verification.UserID,
+		Email: verification.Email,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Invalid or expired token", fmt.Errorf("user %s no longer has email %s", verification.UserID, verification.Email))
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "couldn't verify email", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToUser(user))
+}
+
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	// get the access token
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get token", err)
+		return
+	}
+
+	// validate the token
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "token invalid", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email is already verified", fmt.Errorf("user %s verified at %s", user.ID, user.EmailVerifiedAt.Time))
+		return
+	}
+
+	err = cfg.sendVerificationEmail(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
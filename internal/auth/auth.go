@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"net/http"
@@ -15,6 +16,10 @@ import (
 
 // hash generate
 func HashPassword(password string) (string, error) {
+	// bcrypt would only use the start of the password
+	if len(password) > MaxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
@@ -108,6 +113,13 @@ func MakeRefreshToken() (string, error) {
 	return hex, nil
 }
 
+// one-time tokens sent by email are only stored hashed, so reading the
+// database isn't enough to use them
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // get polka get
 func GetAPIKey(headers http.Header) (string, error) {
 	headerList := headers.Values("Authorization")
@@ -168,4 +168,22 @@ func TestBearerToken(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("MakeRefreshToken() error = %v", err)
+	}
+
+	hash := HashToken(token)
+	if hash == token || len(hash) != 64 {
+		t.Errorf("HashToken() = %v, expected a 64 character hash", hash)
+	}
+	if HashToken(token) != hash {
+		t.Errorf("HashToken() isn't stable for the same token")
+	}
+	if HashToken(token+"x") == hash {
+		t.Errorf("HashToken() gave the same hash for different tokens")
+	}
+}
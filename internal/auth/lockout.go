@@ -0,0 +1,27 @@
+package auth
+
+import "time"
+
+// how long logins are blocked after repeated failures, the delay doubles
+// with every failure past FreeAttempts until it reaches MaxDelay
+type LockoutPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+// the lockout after failures consecutive failed attempts, 0 if there is none
+func (p LockoutPolicy) Delay(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDelay(t *testing.T) {
+	policy := LockoutPolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 2, expected: 0},
+		{failures: 3, expected: time.Second},
+		{failures: 4, expected: 2 * time.Second},
+		{failures: 5, expected: 4 * time.Second},
+		{failures: 8, expected: 32 * time.Second},
+		{failures: 9, expected: time.Minute},
+		{failures: 1000, expected: time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.expected {
+			t.Errorf("Delay(%d) = %v, expected %v", tt.failures, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// bcrypt only looks at the first 72 bytes, anything after that would be
+// silently ignored, so longer passwords are refused instead
+const MaxPasswordBytes = 72
+
+var ErrPasswordTooLong = fmt.Errorf("password is longer than %d bytes", MaxPasswordBytes)
+
+// the names clients can match on in a PolicyError
+const (
+	RuleMinLength = "min_length"
+	RuleMaxBytes  = "max_bytes"
+	RuleCommon    = "common"
+)
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// every rule a password broke, not just the first one
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+type PasswordPolicy struct {
+	// counted in characters, not bytes
+	MinLength int
+	common    map[string]bool
+}
+
+func NewPasswordPolicy(minLength int) *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: minLength,
+		common:    map[string]bool{},
+	}
+}
+
+// reads one password per line, blank lines and lines starting with # are
+// skipped, matching ignores case
+func (p *PasswordPolicy) LoadCommonPasswords(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.common[strings.ToLower(line)] = true
+	}
+	return scanner.Err()
+}
+
+func (p *PasswordPolicy) LoadCommonPasswordsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.LoadCommonPasswords(f)
+}
+
+// returns a *PolicyError if the password breaks any rule
+func (p *PasswordPolicy) Check(password string) error {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("password must be at least %d characters", p.MinLength),
+		})
+	}
+	if len(password) > MaxPasswordBytes {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxBytes,
+			Message: fmt.Sprintf("password must be at most %d bytes", MaxPasswordBytes),
+		})
+	}
+	if p.common[strings.ToLower(password)] {
+		violations = append(violations, Violation{
+			Rule:    RuleCommon,
+			Message: "password is too common",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(8)
+	err := policy.LoadCommonPasswords(strings.NewReader("# top passwords\npassword\n\n  Qwertyuiop  \n"))
+	if err != nil {
+		t.Fatalf("LoadCommonPasswords() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		rules    []string
+	}{
+		{
+			name:     "valid",
+			password: "correct horse battery",
+			rules:    nil,
+		},
+		{
+			name:     "empty",
+			password: "",
+			rules:    []string{RuleMinLength},
+		},
+		{
+			name:     "too short",
+			password: "abc123",
+			rules:    []string{RuleMinLength},
+		},
+		{
+			name:     "length counts characters",
+			password: "ééééééé",
+			rules:    []string{RuleMinLength},
+		},
+		{
+			name:     "too long",
+			password: strings.Repeat("a", MaxPasswordBytes+1),
+			rules:    []string{RuleMaxBytes},
+		},
+		{
+			name:     "exactly the byte limit",
+			password: strings.Repeat("a", MaxPasswordBytes),
+			rules:    nil,
+		},
+		{
+			name:     "multi-byte characters over the byte limit",
+			password: strings.Repeat("é", 40),
+			rules:    []string{RuleMaxBytes},
+		},
+		{
+			name:     "common",
+			password: "Password",
+			rules:    []string{RuleCommon},
+		},
+		{
+			name:     "common list entries are trimmed",
+			password: "qwertyuiop",
+			rules:    []string{RuleCommon},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			if tt.rules == nil {
+				if err != nil {
+					t.Errorf("Check() error = %v, expected none", err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Check() error = %v, expected a *PolicyError", err)
+			}
+			var rules []string
+			for _, violation := range policyErr.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("Check() rules = %v, expected %v", rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyAllViolations(t *testing.T) {
+	policy := NewPasswordPolicy(8)
+	policy.LoadCommonPasswords(strings.NewReader("abc"))
+
+	err := policy.Check("abc")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 2 {
+		t.Fatalf("Check() error = %v, expected two violations", err)
+	}
+}
+
+func TestHashPasswordTooLong(t *testing.T) {
+	_, err := HashPassword(strings.Repeat("a", MaxPasswordBytes+1))
+	if !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("HashPassword() error = %v, expected ErrPasswordTooLong", err)
+	}
+}
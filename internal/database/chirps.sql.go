@@ -7,29 +7,39 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, publish_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at
 `
 
 type CreateChirpParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
-	UserID    uuid.UUID
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Body              string
+	UserID            uuid.UUID
+	ParentID          uuid.NullUUID
+	ReferencedChirpID uuid.NullUUID
+	ReferenceType     sql.NullString
+	PublishAt         sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
@@ -39,6 +49,10 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.ParentID,
+		arg.ReferencedChirpID,
+		arg.ReferenceType,
+		arg.PublishAt,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -47,27 +61,152 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
 	)
 	return i, err
 }
 
-const deleteChirpFromID = `-- name: DeleteChirpFromID :exec
+const deleteRechirpsOfUser = `-- name: DeleteRechirpsOfUser :exec
 DELETE FROM chirps
-WHERE id = $1
+WHERE reference_type = 'rechirp'
+AND referenced_chirp_id IN (
+    SELECT original.id FROM chirps original
+    WHERE original.user_id = $1
+)
 `
 
-func (q *Queries) DeleteChirpFromID(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpFromID, id)
+func (q *Queries) DeleteRechirpsOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOfUser, userID)
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND publish_at IS NOT NULL
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAllChirpsByUser = `-- name: GetAllChirpsByUser :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) GetAllChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT c.id, c.parent_id FROM chirps c
+    WHERE c.id = $1::uuid
+    UNION ALL
+    SELECT c.id, c.parent_id FROM chirps c
+    JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id IN (SELECT ancestors.id FROM ancestors)
+AND id <> $1::uuid
+AND deleted_at IS NULL AND publish_at IS NULL
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, chirpID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT c.id FROM chirps c
+    WHERE c.parent_id = $1::uuid AND c.deleted_at IS NULL AND c.publish_at IS NULL
+    UNION ALL
+    SELECT c.id FROM chirps c
+    JOIN descendants d ON c.parent_id = d.id
+    WHERE c.deleted_at IS NULL AND c.publish_at IS NULL
+)
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id IN (SELECT descendants.id FROM descendants)
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetChirpDescendants(ctx context.Context, chirpID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, chirpID)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +220,11 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -95,9 +239,33 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND publish_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
 const getChirps = `-- name: GetChirps :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND publish_at IS NULL
 `
 
 func (q *Queries) GetChirps(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -109,6 +277,487 @@ func (q *Queries) GetChirps(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL AND publish_at IS NULL
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageAsc = `-- name: GetChirpsPageAsc :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE deleted_at IS NULL AND publish_at IS NULL
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND ($2::uuid IS NULL OR id <> $2::uuid)
+AND (
+    $3::timestamp IS NULL
+    OR (created_at, id) > ($3::timestamp, $4::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type GetChirpsPageAscParams struct {
+	AuthorID        uuid.NullUUID
+	ExcludeID       uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpsPageAsc(ctx context.Context, arg GetChirpsPageAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageAsc,
+		arg.AuthorID,
+		arg.ExcludeID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageDesc = `-- name: GetChirpsPageDesc :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE deleted_at IS NULL AND publish_at IS NULL
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND ($2::uuid IS NULL OR id <> $2::uuid)
+AND (
+    $3::timestamp IS NULL
+    OR (created_at, id) < ($3::timestamp, $4::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetChirpsPageDescParams struct {
+	AuthorID        uuid.NullUUID
+	ExcludeID       uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetChirpsPageDesc(ctx context.Context, arg GetChirpsPageDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageDesc,
+		arg.AuthorID,
+		arg.ExcludeID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirp = `-- name: GetDeletedChirp :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetDeletedChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getScheduledChirpsPage = `-- name: GetScheduledChirpsPage :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at FROM chirps
+WHERE user_id = $1
+AND publish_at IS NOT NULL
+AND (
+    $2::timestamptz IS NULL
+    OR (publish_at, id) > ($2::timestamptz, $3::uuid)
+)
+ORDER BY publish_at ASC, id ASC
+LIMIT $4
+`
+
+type GetScheduledChirpsPageParams struct {
+	UserID          uuid.UUID
+	CursorPublishAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) GetScheduledChirpsPage(ctx context.Context, arg GetScheduledChirpsPageParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsPage,
+		arg.UserID,
+		arg.CursorPublishAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET created_at = $1::timestamp, updated_at = $1::timestamp, publish_at = NULL
+WHERE publish_at <= $2::timestamptz
+RETURNING id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at
+`
+
+type PublishDueChirpsParams struct {
+	PublishedAt time.Time
+	Now         time.Time
+}
+
+func (q *Queries) PublishDueChirps(ctx context.Context, arg PublishDueChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, arg.PublishedAt, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.ReferencedChirpID,
+			&i.ReferenceType,
+			&i.DeletedAt,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1::timestamptz
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const restoreRechirpsOf = `-- name: RestoreRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE referenced_chirp_id = $1 AND reference_type = 'rechirp' AND deleted_at = $2
+`
+
+type RestoreRechirpsOfParams struct {
+	ReferencedChirpID uuid.NullUUID
+	DeletedAt         sql.NullTime
+}
+
+func (q *Queries) RestoreRechirpsOf(ctx context.Context, arg RestoreRechirpsOfParams) error {
+	_, err := q.db.ExecContext(ctx, restoreRechirpsOf, arg.ReferencedChirpID, arg.DeletedAt)
+	return err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.referenced_chirp_id, chirps.reference_type, chirps.deleted_at, chirps.publish_at, ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', $1))::real AS rank
+FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', $1)
+AND deleted_at IS NULL AND publish_at IS NULL
+AND ($2::uuid IS NULL OR user_id = $2::uuid)
+AND (
+    $3::real IS NULL
+    OR (ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', $1))::real, created_at, id)
+        < ($3::real, $4::timestamp, $5::uuid)
+)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type SearchChirpsRow struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.ParentID,
+			&i.Chirp.ReferencedChirpID,
+			&i.Chirp.ReferenceType,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PublishAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = $1
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpParams struct {
+	DeletedAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, arg SoftDeleteChirpParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, arg.DeletedAt, arg.ID)
+	return err
+}
+
+const softDeleteRechirpsOf = `-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = $1
+WHERE referenced_chirp_id = $2 AND reference_type = 'rechirp' AND deleted_at IS NULL
+`
+
+type SoftDeleteRechirpsOfParams struct {
+	DeletedAt         sql.NullTime
+	ReferencedChirpID uuid.NullUUID
+}
+
+func (q *Queries) SoftDeleteRechirpsOf(ctx context.Context, arg SoftDeleteRechirpsOfParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteRechirpsOf, arg.DeletedAt, arg.ReferencedChirpID)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, updated_at = $2
+WHERE id = $3 AND deleted_at IS NULL AND publish_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, deleted_at, publish_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body      string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.UpdatedAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.ReferencedChirpID,
+		&i.ReferenceType,
+		&i.DeletedAt,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -11,12 +11,143 @@ import (
 	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Body              string
+	UserID            uuid.UUID
+	ParentID          uuid.NullUUID
+	ReferencedChirpID uuid.NullUUID
+	ReferenceType     sql.NullString
+	DeletedAt         sql.NullTime
+	PublishAt         sql.NullTime
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpHashtag struct {
+	ChirpID     uuid.UUID
+	HashtagID   uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	Handle      string
+	UserID      uuid.NullUUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type Draft struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	Body         string
+	InReplyTo    uuid.NullUUID
+	QuoteChirpID uuid.NullUUID
+	MediaIds     []uuid.UUID
+	PublishAt    sql.NullTime
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
 	ID        uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type LoginFailure struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	Identifier string
+	UserID     uuid.NullUUID
+	IpAddress  string
+	Reason     string
+}
+
+type LoginThrottle struct {
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	StorageKey   string
+	ThumbnailKey string
+}
+
+type MfaChallenge struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+type ModerationWord struct {
+	Word      string
+	Action    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type PasswordResetToken struct {
+	TokenHash string
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
 	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type RecoveryCode struct {
+	CodeHash  string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
@@ -29,10 +160,20 @@ type RefreshToken struct {
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    bool
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	HashedPassword  sql.NullString
+	IsChirpyRed     bool
+	PinnedChirpID   uuid.NullUUID
+	DisplayName     string
+	Bio             string
+	AvatarMediaID   uuid.NullUUID
+	Handle          sql.NullString
+	HandleChangedAt sql.NullTime
+	EmailVerifiedAt sql.NullTime
+	TotpSecret      sql.NullString
+	TotpEnabledAt   sql.NullTime
+	TotpLastStep    sql.NullInt64
 }
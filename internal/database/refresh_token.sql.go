@@ -81,3 +81,20 @@ func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error
 	_, err := q.db.ExecContext(ctx, revokeToken, arg.RevokedAt, arg.UpdatedAt, arg.Token)
 	return err
 }
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = $1, updated_at = $2
+WHERE user_id = $3 AND revoked_at IS NULL
+`
+
+type RevokeUserRefreshTokensParams struct {
+	RevokedAt sql.NullTime
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+}
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRefreshTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, arg.RevokedAt, arg.UpdatedAt, arg.UserID)
+	return err
+}
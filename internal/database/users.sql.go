@@ -11,26 +11,31 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, handle_changed_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step
 `
 
 type CreateUserParams struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	HashedPassword  sql.NullString
+	Handle          sql.NullString
+	HandleChangedAt sql.NullTime
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -40,6 +45,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.UpdatedAt,
 		arg.Email,
 		arg.HashedPassword,
+		arg.Handle,
+		arg.HandleChangedAt,
 	)
 	var i User
 	err := row.Scan(
@@ -49,12 +56,104 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $1
+WHERE id = $2
+`
+
+type DisableTOTPParams struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) DisableTOTP(ctx context.Context, arg DisableTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = $1, totp_last_step = $2, updated_at = $3
+WHERE id = $4 AND totp_secret = $5 AND totp_enabled_at IS NULL
+`
+
+type EnableTOTPParams struct {
+	TotpEnabledAt sql.NullTime
+	TotpLastStep  sql.NullInt64
+	UpdatedAt     time.Time
+	ID            uuid.UUID
+	TotpSecret    sql.NullString
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableTOTP,
+		arg.TotpEnabledAt,
+		arg.TotpLastStep,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.TotpSecret,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPinnedChirpIDs = `-- name: GetPinnedChirpIDs :many
+SELECT pinned_chirp_id FROM users
+WHERE pinned_chirp_id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetPinnedChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]uuid.NullUUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPinnedChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.NullUUID
+	for rows.Next() {
+		var pinned_chirp_id uuid.NullUUID
+		if err := rows.Scan(&pinned_chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, pinned_chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
 WHERE email = $1
 `
 
@@ -68,10 +167,128 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
+WHERE LOWER(handle) = LOWER($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserProfileStats = `-- name: GetUserProfileStats :one
+SELECT
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = $1 AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = $1) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = $1) AS following_count
+`
+
+type GetUserProfileStatsRow struct {
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetUserProfileStats(ctx context.Context, userID uuid.UUID) (GetUserProfileStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileStats, userID)
+	var i GetUserProfileStatsRow
+	err := row.Scan(&i.ChirpCount, &i.FollowerCount, &i.FollowingCount)
+	return i, err
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -81,24 +298,129 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
-const updateUserWithID = `-- name: UpdateUserWithID :one
+const setPendingTOTPSecret = `-- name: SetPendingTOTPSecret :execrows
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = $3
-WHERE id = $4
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SET totp_secret = $1, totp_last_step = NULL, updated_at = $2
+WHERE id = $3 AND totp_enabled_at IS NULL
 `
 
-type UpdateUserWithIDParams struct {
-	Email          string
+type SetPendingTOTPSecretParams struct {
+	TotpSecret sql.NullString
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetPendingTOTPSecret(ctx context.Context, arg SetPendingTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setPendingTOTPSecret, arg.TotpSecret, arg.UpdatedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setPinnedChirp = `-- name: SetPinnedChirp :exec
+UPDATE users
+SET pinned_chirp_id = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetPinnedChirpParams struct {
+	PinnedChirpID uuid.NullUUID
+	UpdatedAt     time.Time
+	ID            uuid.UUID
+}
+
+func (q *Queries) SetPinnedChirp(ctx context.Context, arg SetPinnedChirpParams) error {
+	_, err := q.db.ExecContext(ctx, setPinnedChirp, arg.PinnedChirpID, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetUserPasswordParams struct {
 	HashedPassword sql.NullString
 	UpdatedAt      time.Time
 	ID             uuid.UUID
 }
 
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.HashedPassword, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = $1, bio = $2, avatar_media_id = $3, handle = $4, handle_changed_at = $5, updated_at = $6
+WHERE id = $7
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UpdateUserProfileParams struct {
+	DisplayName     string
+	Bio             string
+	AvatarMediaID   uuid.NullUUID
+	Handle          sql.NullString
+	HandleChangedAt sql.NullTime
+	UpdatedAt       time.Time
+	ID              uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarMediaID,
+		arg.Handle,
+		arg.HandleChangedAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserWithID = `-- name: UpdateUserWithID :one
+UPDATE users
+SET email = $1, hashed_password = $2, email_verified_at = $3, updated_at = $4
+WHERE id = $5
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UpdateUserWithIDParams struct {
+	Email           string
+	HashedPassword  sql.NullString
+	EmailVerifiedAt sql.NullTime
+	UpdatedAt       time.Time
+	ID              uuid.UUID
+}
+
 func (q *Queries) UpdateUserWithID(ctx context.Context, arg UpdateUserWithIDParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUserWithID,
 		arg.Email,
 		arg.HashedPassword,
+		arg.EmailVerifiedAt,
 		arg.UpdatedAt,
 		arg.ID,
 	)
@@ -110,6 +432,16 @@ func (q *Queries) UpdateUserWithID(ctx context.Context, arg UpdateUserWithIDPara
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -118,7 +450,7 @@ const upgradeUser = `-- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = $1, updated_at = NOW()
 WHERE id = $2
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step
 `
 
 type UpgradeUserParams struct {
@@ -136,6 +468,78 @@ func (q *Queries) UpgradeUser(ctx context.Context, arg UpgradeUserParams) (User,
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $1
+WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+`
+
+type UseTOTPStepParams struct {
+	Step sql.NullInt64
+	ID   uuid.UUID
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = $1, updated_at = $2
+WHERE id = $3 AND email = $4
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, pinned_chirp_id, display_name, bio, avatar_media_id, handle, handle_changed_at, email_verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type VerifyUserEmailParams struct {
+	EmailVerifiedAt sql.NullTime
+	UpdatedAt       time.Time
+	ID              uuid.UUID
+	Email           string
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail,
+		arg.EmailVerifiedAt,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.Email,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.PinnedChirpID,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Handle,
+		&i.HandleChangedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
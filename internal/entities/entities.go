@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+type Kind string
+
+const (
+	Hashtag Kind = "hashtag"
+	Mention Kind = "mention"
+)
+
+// an entity found in a chirp body, Start and End are offsets into the body
+// in grapheme clusters, the same characters chirp length is counted in, with
+// End exclusive and both covering the leading # or @
+type Entity struct {
+	Kind  Kind
+	Text  string
+	Start int
+	End   int
+}
+
+// find the #hashtags and @mentions in a body, normalized to lower case
+func Parse(body string) []Entity {
+	clusters := []string{}
+	graphemes := uniseg.NewGraphemes(body)
+	for graphemes.Next() {
+		clusters = append(clusters, graphemes.Str())
+	}
+	found := []Entity{}
+
+	for i := 0; i < len(clusters); i++ {
+		var kind Kind
+		switch clusters[i] {
+		case "#":
+			kind = Hashtag
+		case "@":
+			kind = Mention
+		default:
+			continue
+		}
+
+		// an entity has to start a word, so "a@b.com" isn't a mention
+		if i > 0 && (isEntityCluster(clusters[i-1]) || clusters[i-1] == "#" || clusters[i-1] == "@") {
+			continue
+		}
+
+		end := i + 1
+		for end < len(clusters) && isEntityCluster(clusters[end]) {
+			end++
+		}
+
+		text := strings.Join(clusters[i+1:end], "")
+		if !isValidEntityText(kind, text) {
+			i = end - 1
+			continue
+		}
+
+		found = append(found, Entity{
+			Kind:  kind,
+			Text:  strings.ToLower(text),
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+
+	return found
+}
+
+// a character belongs to an entity if it starts with a letter, digit or
+// underscore, any combining marks after it come along with it
+func isEntityCluster(cluster string) bool {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	return isEntityRune(r)
+}
+
+func isEntityRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hashtags need at least one letter so "#1" isn't a tag, mentions only
+// allow the characters a handle can have
+func isValidEntityText(kind Kind, text string) bool {
+	if text == "" {
+		return false
+	}
+
+	switch kind {
+	case Hashtag:
+		for _, r := range text {
+			if unicode.IsLetter(r) {
+				return true
+			}
+		}
+		return false
+	case Mention:
+		for _, r := range text {
+			if r > unicode.MaxASCII {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
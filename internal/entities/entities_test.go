@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected []Entity
+	}{
+		{
+			name:     "no entities",
+			input:    "just a normal chirp",
+			expected: []Entity{},
+		},
+		{
+			name:  "hashtag and mention",
+			input: "hello @Alice check #GoLang",
+			expected: []Entity{
+				{Kind: Mention, Text: "alice", Start: 6, End: 12},
+				{Kind: Hashtag, Text: "golang", Start: 19, End: 26},
+			},
+		},
+		{
+			name:  "punctuation ends an entity",
+			input: "#chirpy! (@bob_2)",
+			expected: []Entity{
+				{Kind: Hashtag, Text: "chirpy", Start: 0, End: 7},
+				{Kind: Mention, Text: "bob_2", Start: 10, End: 16},
+			},
+		},
+		{
+			name:     "email is not a mention",
+			input:    "mail me at alice@example.com",
+			expected: []Entity{},
+		},
+		{
+			name:     "number only is not a hashtag",
+			input:    "we're #1",
+			expected: []Entity{},
+		},
+		{
+			name:  "offsets count characters not bytes",
+			input: "🎉🎉 #café",
+			expected: []Entity{
+				{Kind: Hashtag, Text: "café", Start: 3, End: 8},
+			},
+		},
+		{
+			name:  "offsets count grapheme clusters not runes",
+			input: "👍🏽 #cafe\u0301 ok",
+			expected: []Entity{
+				{Kind: Hashtag, Text: "cafe\u0301", Start: 2, End: 7},
+			},
+		},
+		{
+			name:     "non ascii mention is ignored",
+			input:    "hi @zoë",
+			expected: []Entity{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Parse(c.input)
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("Parse(%q) = %+v, expected %+v", c.input, got, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 15
+)
+
+var (
+	ErrLength     = fmt.Errorf("handles must be between %d and %d characters", MinLength, MaxLength)
+	ErrCharacters = errors.New("handles can only contain letters, numbers and underscores")
+	ErrNoLetter   = errors.New("handles need at least one letter")
+	ErrReserved   = errors.New("that handle is reserved")
+)
+
+// handles that look like they belong to the site or clash with our routes
+var reserved = map[string]struct{}{
+	"about":     {},
+	"admin":     {},
+	"api":       {},
+	"app":       {},
+	"chirpy":    {},
+	"everyone":  {},
+	"help":      {},
+	"here":      {},
+	"login":     {},
+	"logout":    {},
+	"me":        {},
+	"moderator": {},
+	"null":      {},
+	"root":      {},
+	"settings":  {},
+	"signup":    {},
+	"support":   {},
+	"system":    {},
+	"undefined": {},
+}
+
+// strip the surrounding space and a leading @ from a handle the user typed
+func Normalize(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
+
+// check a normalized handle, the allowed characters are the ones
+// entities.Parse accepts in a mention so every handle can be mentioned
+func Validate(handle string) error {
+	if len(handle) < MinLength || len(handle) > MaxLength {
+		return ErrLength
+	}
+
+	hasLetter := false
+	for _, r := range handle {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			hasLetter = true
+		case r >= '0' && r <= '9', r == '_':
+		default:
+			return ErrCharacters
+		}
+	}
+	if !hasLetter {
+		return ErrNoLetter
+	}
+
+	if _, ok := reserved[strings.ToLower(handle)]; ok {
+		return ErrReserved
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package handles
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected error
+	}{
+		{
+			name:     "valid",
+			input:    "bob_2",
+			expected: nil,
+		},
+		{
+			name:     "mixed case",
+			input:    "BobTheBuilder",
+			expected: nil,
+		},
+		{
+			name:     "too short",
+			input:    "bo",
+			expected: ErrLength,
+		},
+		{
+			name:     "too long",
+			input:    "a_very_long_handle",
+			expected: ErrLength,
+		},
+		{
+			name:     "punctuation",
+			input:    "bob.smith",
+			expected: ErrCharacters,
+		},
+		{
+			name:     "non ascii",
+			input:    "café",
+			expected: ErrCharacters,
+		},
+		{
+			name:     "numbers only",
+			input:    "12345",
+			expected: ErrNoLetter,
+		},
+		{
+			name:     "reserved",
+			input:    "Admin",
+			expected: ErrReserved,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Validate(c.input)
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Validate(%q) = %v, expected %v", c.input, err, c.expected)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"bob":     "bob",
+		" @bob ":  "bob",
+		"@@bob":   "@bob",
+		"Bob_2\n": "Bob_2",
+	}
+
+	for input, expected := range cases {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// sends mail to users, the server doesn't care how it gets there
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// sends mail through an SMTP server, logging in with PLAIN auth when a
+// username is set
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	s := &SMTP{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		from: from,
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, formatMessage(s.from, msg, time.Now()))
+}
+
+// writes every message to a file or stream instead of sending it, for
+// running the server locally
+type Writer struct {
+	mu   sync.Mutex
+	from string
+	w    io.Writer
+}
+
+func NewWriter(w io.Writer, from string) *Writer {
+	return &Writer{
+		from: from,
+		w:    w,
+	}
+}
+
+// appends messages to the file at path, creating it if needed
+func NewFile(path, from string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(f, from), nil
+}
+
+func (wr *Writer) Send(ctx context.Context, msg Message) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	_, err := wr.w.Write(formatMessage(wr.from, msg, time.Now()))
+	if err != nil {
+		return err
+	}
+	// keep messages apart when several end up in the same file
+	_, err = io.WriteString(wr.w, "\r\n")
+	return err
+}
+
+// build the raw message, header values can't contain line breaks or they
+// could be used to add headers of their own
+func formatMessage(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMessage(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := string(formatMessage("chirpy@example.com", Message{
+		To:      "user@example.com",
+		Subject: "Hello\r\nBcc: someone@example.com",
+		Body:    "line one\nline two",
+	}, now))
+
+	expected := "From: chirpy@example.com\r\n" +
+		"To: user@example.com\r\n" +
+		"Subject: HelloBcc: someone@example.com\r\n" +
+		"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"line one\r\nline two\r\n"
+	if got != expected {
+		t.Errorf("unexpected message:\n%q\nexpected:\n%q", got, expected)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var m Mailer = NewWriter(&buf, "chirpy@example.com")
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		err := m.Send(context.Background(), Message{To: to, Subject: "Hi", Body: "body"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "To: a@example.com\r\n") || !strings.Contains(out, "To: b@example.com\r\n") {
+		t.Errorf("expected both messages to be written, got %q", out)
+	}
+	if strings.Count(out, "From: chirpy@example.com\r\n") != 2 {
+		t.Errorf("expected two messages, got %q", out)
+	}
+}
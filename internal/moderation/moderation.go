@@ -0,0 +1,238 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type Action string
+
+const (
+	// replace the offending text with asterisks
+	ActionMask Action = "mask"
+	// refuse the whole chirp
+	ActionReject Action = "reject"
+)
+
+const mask = "****"
+
+// parse an action, as written in rule files and admin requests
+func ParseAction(action string) (Action, error) {
+	switch Action(strings.ToLower(strings.TrimSpace(action))) {
+	case ActionMask:
+		return ActionMask, nil
+	case ActionReject:
+		return ActionReject, nil
+	}
+	return "", fmt.Errorf("unknown moderation action %q", action)
+}
+
+// returned when a filter refuses a body outright
+type RejectedError struct {
+	Rule string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("chirp rejected by moderation rule %q", e.Rule)
+}
+
+// a single step in the pipeline, returns the (possibly masked) body or a
+// *RejectedError
+type Filter interface {
+	Filter(body string) (string, error)
+}
+
+// runs filters in order, each seeing the output of the one before it
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+func (p *Pipeline) Moderate(body string) (string, error) {
+	var err error
+	for _, filter := range p.filters {
+		body, err = filter.Filter(body)
+		if err != nil {
+			return "", err
+		}
+	}
+	return body, nil
+}
+
+type WordRule struct {
+	Word   string
+	Action Action
+}
+
+// matches whole words regardless of case or the punctuation around them,
+// safe to swap out with Replace while chirps are being filtered
+type WordList struct {
+	mu    sync.RWMutex
+	words map[string]Action
+}
+
+func NewWordList(rules []WordRule) *WordList {
+	list := &WordList{}
+	list.Replace(rules)
+	return list
+}
+
+func (l *WordList) Replace(rules []WordRule) {
+	words := make(map[string]Action, len(rules))
+	for _, rule := range rules {
+		words[NormalizeWord(rule.Word)] = rule.Action
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.words = words
+}
+
+func (l *WordList) Filter(body string) (string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var builder strings.Builder
+	runes := []rune(body)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			builder.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		word := string(runes[i:end])
+
+		switch l.words[NormalizeWord(word)] {
+		case ActionReject:
+			return "", &RejectedError{Rule: NormalizeWord(word)}
+		case ActionMask:
+			builder.WriteString(mask)
+		default:
+			builder.WriteString(word)
+		}
+		i = end
+	}
+
+	return builder.String(), nil
+}
+
+// lower case a word the same way the word list does when matching
+func NormalizeWord(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+// a word list entry has to be a single word, or it could never match
+func ValidateWord(word string) error {
+	word = NormalizeWord(word)
+	if word == "" {
+		return fmt.Errorf("word is empty")
+	}
+	for _, r := range word {
+		if !isWordRune(r) {
+			return fmt.Errorf("word %q contains %q, only letters and digits are matched", word, r)
+		}
+	}
+	return nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+type RegexRule struct {
+	Pattern *regexp.Regexp
+	Action  Action
+}
+
+// applies each rule in order, masking every match or rejecting on the first
+type RegexFilter struct {
+	rules []RegexRule
+}
+
+func NewRegexFilter(rules []RegexRule) *RegexFilter {
+	return &RegexFilter{rules: rules}
+}
+
+func (f *RegexFilter) Filter(body string) (string, error) {
+	for _, rule := range f.rules {
+		if !rule.Pattern.MatchString(body) {
+			continue
+		}
+		if rule.Action == ActionReject {
+			return "", &RejectedError{Rule: rule.Pattern.String()}
+		}
+		body = rule.Pattern.ReplaceAllString(body, mask)
+	}
+	return body, nil
+}
+
+// load word and regex rules from a file with one rule per line:
+//
+//	<mask|reject> word <word>
+//	<mask|reject> regex <pattern>
+//
+// blank lines and lines starting with # are skipped
+func LoadRulesFile(path string) ([]WordRule, []RegexRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	words := []WordRule{}
+	regexes := []RegexRule{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, nil, fmt.Errorf("%s:%d: expected \"<action> <word|regex> <rule>\"", path, lineNumber)
+		}
+
+		action, err := ParseAction(fields[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+
+		switch fields[1] {
+		case "word":
+			err := ValidateWord(fields[2])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			words = append(words, WordRule{Word: fields[2], Action: action})
+		case "regex":
+			pattern, err := regexp.Compile(fields[2])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			regexes = append(regexes, RegexRule{Pattern: pattern, Action: action})
+		default:
+			return nil, nil, fmt.Errorf("%s:%d: unknown rule type %q", path, lineNumber, fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return words, regexes, nil
+}
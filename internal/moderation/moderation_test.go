@@ -0,0 +1,118 @@
+package moderation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWordList(t *testing.T) {
+	list := NewWordList([]WordRule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "Sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionReject},
+	})
+
+	cases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			input:    "This is a kerfuffle opinion I need to share with the world",
+			expected: "This is a **** opinion I need to share with the world",
+		},
+		{
+			input:    "Kerfuffle! what a SHARBERT.",
+			expected: "****! what a ****.",
+		},
+		{
+			input:    "kerfuffles are fine",
+			expected: "kerfuffles are fine",
+		},
+		{
+			input:    "«kerfuffle»\tand\nsharbert",
+			expected: "«****»\tand\n****",
+		},
+		{
+			input:   "look at fornax",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		got, err := list.Filter(c.input)
+		if c.wantErr {
+			var rejected *RejectedError
+			if !errors.As(err, &rejected) {
+				t.Errorf("Filter(%q) expected a RejectedError, got %v", c.input, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Filter(%q) unexpected error: %v", c.input, err)
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("Filter(%q) = %q, expected %q", c.input, got, c.expected)
+		}
+	}
+
+	list.Replace([]WordRule{})
+	if got, _ := list.Filter("kerfuffle"); got != "kerfuffle" {
+		t.Errorf("expected replaced word list to stop masking, got %q", got)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := NewPipeline(
+		NewWordList([]WordRule{{Word: "kerfuffle", Action: ActionMask}}),
+		NewRegexFilter([]RegexRule{
+			{Pattern: regexp.MustCompile(`\d{3}-\d{4}`), Action: ActionMask},
+			{Pattern: regexp.MustCompile(`(?i)buy now`), Action: ActionReject},
+		}),
+	)
+
+	got, err := pipeline.Moderate("call kerfuffle at 555-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "call **** at ****" {
+		t.Errorf("got %q", got)
+	}
+
+	_, err = pipeline.Moderate("BUY NOW")
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		t.Errorf("expected a RejectedError, got %v", err)
+	}
+}
+
+func TestLoadRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	contents := "# comment\n\nmask word kerfuffle\nreject regex (?i)buy now\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	words, regexes, err := LoadRulesFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(words) != 1 || words[0].Word != "kerfuffle" || words[0].Action != ActionMask {
+		t.Errorf("unexpected words: %+v", words)
+	}
+	if len(regexes) != 1 || regexes[0].Action != ActionReject {
+		t.Errorf("unexpected regexes: %+v", regexes)
+	}
+
+	badPath := filepath.Join(t.TempDir(), "bad.txt")
+	if err := os.WriteFile(badPath, []byte("delete word kerfuffle\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadRulesFile(badPath); err == nil {
+		t.Errorf("expected an error for an unknown action")
+	}
+}
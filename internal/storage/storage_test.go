@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	root := t.TempDir()
+	local, err := NewLocal(root, "/app/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	err = local.Put(ctx, "images/a.png", "image/png", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(root, "images", "a.png"))
+	if err != nil || string(contents) != "data" {
+		t.Errorf("expected file to be written, got %q, %v", contents, err)
+	}
+
+	if url := local.URL("images/a.png"); url != "/app/media/images/a.png" {
+		t.Errorf("unexpected url %q", url)
+	}
+
+	err = local.Delete(ctx, "images/a.png")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "images", "a.png")); !os.IsNotExist(err) {
+		t.Errorf("expected file to be deleted")
+	}
+
+	// deleting twice is fine
+	if err := local.Delete(ctx, "images/a.png"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/app/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"", "../secret", "a/../../b", "/etc/passwd", "a//b"}
+	for _, key := range keys {
+		if err := local.Put(context.Background(), key, "text/plain", strings.NewReader("x")); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// the decoded source and the encoded thumbnail
+type Result struct {
+	Width       int
+	Height      int
+	Data        []byte
+	ContentType string
+}
+
+// images bigger than this are refused before decoding, a small file can
+// claim huge dimensions
+const MaxPixels = 40_000_000
+
+// decode a jpeg, png or gif and scale it down so neither side is longer
+// than maxSize, jpegs stay jpegs and everything else becomes a png so
+// transparency survives
+func Make(data []byte, contentType string, maxSize int) (Result, error) {
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case "image/gif":
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	default:
+		return Result{}, fmt.Errorf("unsupported image type %q", contentType)
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+	if config.Width*config.Height > MaxPixels {
+		return Result{}, fmt.Errorf("image is %dx%d, the limit is %d pixels", config.Width, config.Height, MaxPixels)
+	}
+
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+
+	bounds := src.Bounds()
+	thumb := scale(src, maxSize)
+
+	var buf bytes.Buffer
+	outType := "image/png"
+	if contentType == "image/jpeg" {
+		outType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Data:        buf.Bytes(),
+		ContentType: outType,
+	}, nil
+}
+
+// box filter downscale, each output pixel is the average of the source
+// pixels it covers
+func scale(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return src
+	}
+
+	newWidth, newHeight := maxSize, maxSize
+	if width > height {
+		newHeight = max(1, height*maxSize/width)
+	} else {
+		newWidth = max(1, width*maxSize/height)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		y0 := bounds.Min.Y + y*height/newHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/newHeight)
+		for x := 0; x < newWidth; x++ {
+			x0 := bounds.Min.X + x*width/newWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/newWidth)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pixel := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					r += uint64(pixel.R)
+					g += uint64(pixel.G)
+					b += uint64(pixel.B)
+					a += uint64(pixel.A)
+					n++
+				}
+			}
+			dst.Set(x, y, color.NRGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
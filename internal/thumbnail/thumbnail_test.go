@@ -0,0 +1,64 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	cases := []struct {
+		name           string
+		width          int
+		height         int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "landscape", width: 640, height: 320, expectedWidth: 100, expectedHeight: 50},
+		{name: "portrait", width: 300, height: 600, expectedWidth: 50, expectedHeight: 100},
+		{name: "already small", width: 40, height: 20, expectedWidth: 40, expectedHeight: 20},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, c.width, c.height))
+			for y := 0; y < c.height; y++ {
+				for x := 0; x < c.width; x++ {
+					src.Set(x, y, color.NRGBA{R: 255, A: 255})
+				}
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, src); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Make(buf.Bytes(), "image/png", 100)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Width != c.width || result.Height != c.height {
+				t.Errorf("expected source size %dx%d, got %dx%d", c.width, c.height, result.Width, result.Height)
+			}
+
+			thumb, err := png.Decode(bytes.NewReader(result.Data))
+			if err != nil {
+				t.Fatalf("couldn't decode thumbnail: %v", err)
+			}
+			if thumb.Bounds().Dx() != c.expectedWidth || thumb.Bounds().Dy() != c.expectedHeight {
+				t.Errorf("expected thumbnail %dx%d, got %dx%d", c.expectedWidth, c.expectedHeight, thumb.Bounds().Dx(), thumb.Bounds().Dy())
+			}
+			r, _, _, _ := thumb.At(0, 0).RGBA()
+			if r>>8 != 255 {
+				t.Errorf("expected the color to survive scaling, got red %d", r>>8)
+			}
+		})
+	}
+}
+
+func TestMakeRejectsUnknownTypes(t *testing.T) {
+	if _, err := Make([]byte("GIF89a"), "image/webp", 100); err == nil {
+		t.Errorf("expected an error for an unsupported type")
+	}
+}
@@ -0,0 +1,128 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test vectors from RFC 6238 appendix B, SHA1 with an 8 digit code
+func TestHOTPRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "94287082"},
+		{unix: 1111111109, expected: "07081804"},
+		{unix: 1111111111, expected: "14050471"},
+		{unix: 1234567890, expected: "89005924"},
+		{unix: 2000000000, expected: "69279037"},
+		{unix: 20000000000, expected: "65353130"},
+	}
+
+	for _, tt := range tests {
+		got := hotp(key, uint64(Step(time.Unix(tt.unix, 0))), 8)
+		if got != tt.expected {
+			t.Errorf("hotp() at %d = %v, expected %v", tt.unix, got, tt.expected)
+		}
+	}
+}
+
+func TestCodeAndValidate(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+	if code != "050471" {
+		t.Errorf("Code() = %v, expected 050471", code)
+	}
+
+	step, ok := Validate(secret, code, now, 1)
+	if !ok || step != Step(now) {
+		t.Errorf("Validate() = %v, %v, expected step %v", step, ok, Step(now))
+	}
+
+	// one step of clock drift is allowed, two isn't
+	if _, ok := Validate(secret, code, now.Add(Period), 1); !ok {
+		t.Errorf("Validate() rejected a code from the previous step")
+	}
+	if _, ok := Validate(secret, code, now.Add(2*Period), 1); ok {
+		t.Errorf("Validate() accepted a code from two steps ago")
+	}
+
+	if _, ok := Validate(secret, "000000", now, 1); ok {
+		t.Errorf("Validate() accepted a wrong code")
+	}
+	if _, ok := Validate(secret, "12345", now, 1); ok {
+		t.Errorf("Validate() accepted a short code")
+	}
+	if _, ok := Validate("not base32!", code, now, 1); ok {
+		t.Errorf("Validate() accepted an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil || len(key) != secretBytes {
+		t.Errorf("GenerateSecret() = %v, expected %d bytes of base32", secret, secretBytes)
+	}
+
+	other, _ := GenerateSecret()
+	if other == secret {
+		t.Errorf("GenerateSecret() returned the same secret twice")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("JBSWY3DPEHPK3PXP", "Chirpy", "user@example.com")
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("URI() = %v, not a valid URL: %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI() = %v, expected otpauth://totp/", uri)
+	}
+	if parsed.Path != "/Chirpy:user@example.com" {
+		t.Errorf("URI() label = %v", parsed.Path)
+	}
+	query := parsed.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Chirpy" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("URI() query = %v", query)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := RecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("RecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("RecoveryCodes() returned %d codes, expected 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("RecoveryCodes() code %v isn't formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("RecoveryCodes() returned %v twice", code)
+		}
+		seen[code] = true
+
+		if NormalizeRecoveryCode(" "+strings.ToUpper(code)+" ") != strings.ReplaceAll(code, "-", "") {
+			t.Errorf("NormalizeRecoveryCode() didn't normalize %v", code)
+		}
+	}
+}
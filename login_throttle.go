@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+// failures further apart than this don't add up
+const loginFailureWindow = 24 * time.Hour
+
+// an IP gets more room than an account since many people can share one
+var (
+	accountLockout = auth.LockoutPolicy{
+		FreeAttempts: 5,
+		BaseDelay: 30 * time.Second,
+		MaxDelay: time.Hour,
+	}
+	ipLockout = auth.LockoutPolicy{
+		FreeAttempts: 20,
+		BaseDelay: 30 * time.Second,
+		MaxDelay: time.Hour,
+	}
+)
+
+// why a login attempt failed, stored with the audit record
+const (
+	loginFailureUnknownAccount = "unknown_account"
+	loginFailureBadPassword = "bad_password"
+	loginFailureBadMFACode = "bad_mfa_code"
+	loginFailureBadConfirmation = "bad_password_confirmation"
+)
+
+func accountThrottleKey(userID uuid.UUID) string {
+	return "account:" + userID.String()
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mfaThrottleKey(userID uuid.UUID) string {
+	return "mfa:" + userID.String()
+}
+
+func resetEmailThrottleKey(email string) string {
+	return "reset-email:" + strings.ToLower(email)
+}
+
+func resetIPThrottleKey(ip string) string {
+	return "reset-ip:" + ip
+}
+
+// the address the request came from, X-Forwarded-For can be set by anyone
+// so it's only used when TRUST_PROXY_HEADERS says a proxy in front sets it
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func respondWithLockout(w http.ResponseWriter, lockedUntil time.Time, msg string) {
+	wait := time.Until(lockedUntil)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, msg, fmt.Errorf("locked until %s", lockedUntil))
+}
+
+// counts an attempt against every key before it is made, so requests
+// running at the same time can't all get in under the limit, and locks a
+// key out once it has had too many. if a key is already locked nothing is
+// counted and the time it opens again is returned
+func (cfg *apiConfig) reserveAttempt(ctx context.Context, policies map[string]auth.LockoutPolicy) (time.Time, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// the upsert holds each row until commit, taking them in the same order
+	// every time keeps two requests from waiting on each other
+	keys := make([]string, 0, len(policies))
+	for key := range policies {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	now := time.Now()
+	var lockedUntil time.Time
+	lockouts := map[string]time.Duration{}
+	for _, key := range keys {
+		throttle, err := qtx.RecordLoginThrottleAttempt(ctx, database.RecordLoginThrottleAttemptParams{
+			Key: key,
+			Now: now,
+			WindowStart: now.Add(-loginFailureWindow),
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(now) {
+			if throttle.LockedUntil.Time.After(lockedUntil) {
+				lockedUntil = throttle.LockedUntil.Time
+			}
+			continue
+		}
+		if delay := policies[key].Delay(int(throttle.Failures)); delay > 0 {
+			lockouts[key] = delay
+		}
+	}
+	if !lockedUntil.IsZero() {
+		return lockedUntil, nil
+	}
+
+	for key, delay := range lockouts {
+		err = qtx.SetLoginLockout(ctx, database.SetLoginLockoutParams{
+			LockedUntil: sql.NullTime{Time: now.Add(delay), Valid: true},
+			Key: key,
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Time{}, tx.Commit()
+}
+
+// hands back an attempt reserved for a request that went fine, so only
+// failures add up, lifting the lockout if that takes the key back under
+func (cfg *apiConfig) releaseAttempt(ctx context.Context, key string, policy auth.LockoutPolicy) error {
+	return cfg.dbQueries.ReleaseLoginThrottleAttempt(ctx, database.ReleaseLoginThrottleAttemptParams{
+		FreeAttempts: int32(policy.FreeAttempts),
+		Key: key,
+	})
+}
+
+// reserves a login attempt against the IP, and the account if there is one,
+// responding with a 429 and returning false if either is locked out
+func (cfg *apiConfig) reserveLoginAttempt(w http.ResponseWriter, r *http.Request, userID uuid.NullUUID) bool {
+	policies := map[string]auth.LockoutPolicy{
+		ipThrottleKey(cfg.clientIP(r)): ipLockout,
+	}
+	if userID.Valid {
+		policies[accountThrottleKey(userID.UUID)] = accountLockout
+	}
+
+	lockedUntil, err := cfg.reserveAttempt(r.Context(), policies)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check login attempts", err)
+		return false
+	}
+	if !lockedUntil.IsZero() {
+		respondWithLockout(w, lockedUntil, "Too many failed login attempts, try again later")
+		return false
+	}
+	return true
+}
+
+// the attempt was already counted when it was reserved, this keeps the audit
+// record, failing to write it shouldn't stop the response but it's logged
+func (cfg *apiConfig) logLoginFailure(r *http.Request, identifier string, userID uuid.NullUUID, reason string) {
+	err := cfg.dbQueries.CreateLoginFailure(r.Context(), database.CreateLoginFailureParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		Identifier: identifier,
+		UserID: userID,
+		IpAddress: cfg.clientIP(r),
+		Reason: reason,
+	})
+	if err != nil {
+		log.Printf("Couldn't record failed login for %q: %s", identifier, err)
+	}
+}
+
+// checks the password a signed-in user sends to confirm a sensitive change,
+// wrong guesses count against the same account lockout as logins so an
+// access token can't be used to try passwords without limit. responds and
+// returns false if the account is locked or the password is wrong
+func (cfg *apiConfig) confirmPassword(w http.ResponseWriter, r *http.Request, user database.User, password string, msg string) bool {
+	key := accountThrottleKey(user.ID)
+	lockedUntil, err := cfg.reserveAttempt(r.Context(), map[string]auth.LockoutPolicy{
+		key: accountLockout,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check password attempts", err)
+		return false
+	}
+	if !lockedUntil.IsZero() {
+		respondWithLockout(w, lockedUntil, "Too many wrong passwords, try again later")
+		return false
+	}
+
+	err = auth.CheckPasswordHash(user.HashedPassword.String, password)
+	if err != nil {
+		cfg.logLoginFailure(r, user.Email, uuid.NullUUID{UUID: user.ID, Valid: true}, loginFailureBadConfirmation)
+		respondWithError(w, http.StatusUnauthorized, msg, err)
+		return false
+	}
+
+	// the right password starts the account over, same as a login
+	err = cfg.dbQueries.ClearLoginThrottle(r.Context(), key)
+	if err != nil {
+		log.Printf("Couldn't clear password attempts for user %s: %s", user.ID, err)
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/kyoukyuubi/chirpy/internal/mailer"
+)
+
+// pick how mail goes out from MAILER, "smtp" for a real server, "file" to
+// append everything to MAILER_FILE, or "log" to print it. mail carries
+// verification links and reset tokens, so only dev falls back to the log
+// when MAILER isn't set
+func newMailerFromEnv(platform string) (mailer.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "chirpy@localhost"
+	}
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be set when MAILER is smtp")
+		}
+		port := 587
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("SMTP_PORT must be a number: %w", err)
+			}
+			port = parsed
+		}
+		return mailer.NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return mailer.NewFile(path, from)
+	case "":
+		if platform != "dev" {
+			return nil, fmt.Errorf("MAILER must be set outside dev")
+		}
+		return mailer.NewWriter(log.Writer(), from), nil
+	case "log":
+		return mailer.NewWriter(log.Writer(), from), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", os.Getenv("MAILER"))
+	}
+}
+
+// a link back to the API with the token in the query string
+func (cfg *apiConfig) tokenLink(path, token string) string {
+	return cfg.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (cfg *apiConfig) sendMail(ctx context.Context, to, subject, body string) error {
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To: to,
+		Subject: subject,
+		Body: body,
+	})
+}
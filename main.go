@@ -1,23 +1,44 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kyoukyuubi/chirpy/internal/auth"
 	"github.com/kyoukyuubi/chirpy/internal/database"
+	"github.com/kyoukyuubi/chirpy/internal/mailer"
+	"github.com/kyoukyuubi/chirpy/internal/moderation"
+	"github.com/kyoukyuubi/chirpy/internal/storage"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db *sql.DB
 	dbQueries *database.Queries
 	platform string
 	secret string
 	polkaKey string
+	adminKey string
+	moderator *moderation.Pipeline
+	moderationWords *moderation.WordList
+	chirpLimits chirpLengthLimits
+	storage storage.Storage
+	restoreWindow time.Duration
+	mailer mailer.Mailer
+	baseURL string
+	unverifiedRestrictions map[string]bool
+	passwordPolicy *auth.PasswordPolicy
+	trustProxyHeaders bool
 }
 
 func main() {
@@ -43,6 +64,56 @@ func main() {
 		log.Fatal("POLKA_KEY must be set")
 	}
 
+	chirpLimits := chirpLengthLimits{
+		Default: 140,
+		ChirpyRed: 280,
+	}
+	if limit := os.Getenv("CHIRP_MAX_LENGTH"); limit != "" {
+		maxLength, err := strconv.Atoi(limit)
+		if err != nil {
+			log.Fatalf("CHIRP_MAX_LENGTH must be a number: %v", err)
+		}
+		chirpLimits.Default = maxLength
+	}
+	if limit := os.Getenv("CHIRP_MAX_LENGTH_RED"); limit != "" {
+		maxLength, err := strconv.Atoi(limit)
+		if err != nil {
+			log.Fatalf("CHIRP_MAX_LENGTH_RED must be a number: %v", err)
+		}
+		chirpLimits.ChirpyRed = maxLength
+	}
+
+	// how long a deleted chirp can be restored before it's purged
+	restoreWindow := 7 * 24 * time.Hour
+	if window := os.Getenv("CHIRP_RESTORE_WINDOW"); window != "" {
+		duration, err := time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("CHIRP_RESTORE_WINDOW must be a duration like 168h: %v", err)
+		}
+		restoreWindow = duration
+	}
+
+	// used to build the links sent in emails
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+
+	appMailer, err := newMailerFromEnv(platform)
+	if err != nil {
+		log.Fatalf("Couldn't set up mailer: %v", err)
+	}
+
+	unverifiedRestrictions, err := parseUnverifiedRestrictions(os.Getenv("UNVERIFIED_RESTRICTIONS"))
+	if err != nil {
+		log.Fatalf("UNVERIFIED_RESTRICTIONS is invalid: %v", err)
+	}
+
+	passwordPolicy, err := newPasswordPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Couldn't set up password policy: %v", err)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Printf("Error connecting to db: %v", err)
@@ -51,14 +122,40 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	// uploads are kept next to the other files served under /app/
+	mediaStorage, err := storage.NewLocal(filepath.Join(filepathRoot, "media"), "/app/media")
+	if err != nil {
+		log.Fatalf("Couldn't set up media storage: %v", err)
+	}
+
 	cfg := apiConfig{
 		fileserverHits: atomic.Int32{},
+		db: db,
 		dbQueries: dbQueries,
 		platform: platform,
 		secret: secret,
 		polkaKey: polkaKey,
+		adminKey: os.Getenv("ADMIN_KEY"),
+		chirpLimits: chirpLimits,
+		storage: mediaStorage,
+		restoreWindow: restoreWindow,
+		mailer: appMailer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		unverifiedRestrictions: unverifiedRestrictions,
+		passwordPolicy: passwordPolicy,
+		// only turn this on behind a proxy that sets X-Forwarded-For
+		trustProxyHeaders: os.Getenv("TRUST_PROXY_HEADERS") == "true",
+	}
+
+	err = cfg.setupModeration(context.Background(), os.Getenv("MODERATION_RULES_FILE"))
+	if err != nil {
+		log.Fatalf("Couldn't set up moderation: %v", err)
 	}
 
+	go cfg.runChirpPurge(context.Background(), time.Hour)
+	go cfg.runChirpScheduler(context.Background(), 15*time.Second)
+	go cfg.runModerationReload(context.Background(), time.Minute)
+
 	mux := http.NewServeMux()
 	handler := http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))
 	mux.Handle("/app/", cfg.middlewareMetricsInc(handler))
@@ -67,13 +164,61 @@ func main() {
 
 	mux.HandleFunc("POST /api/chirps", cfg.handlerChirpCreate)
 	mux.HandleFunc("GET /api/chirps", cfg.handlerChirpSelectAll)
+	mux.HandleFunc("GET /api/chirps/search", cfg.handlerChirpSearch)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handlerChirpSelect)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.handlerChirpUpdate)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", cfg.handlerChirpUpdate)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.handlerDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.handlerChirpRestore)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/history", cfg.handlerChirpHistory)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.handlerChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", cfg.handlerRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", cfg.handlerChirpLike)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", cfg.handlerChirpUnlike)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.handlerChirpBookmark)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.handlerChirpUnbookmark)
+
+	mux.HandleFunc("GET /api/scheduled-chirps", cfg.handlerScheduledChirps)
+	mux.HandleFunc("DELETE /api/scheduled-chirps/{chirpID}", cfg.handlerDeleteScheduledChirp)
+
+	mux.HandleFunc("POST /api/media", cfg.handlerMediaUpload)
+
+	mux.HandleFunc("POST /api/drafts", cfg.handlerDraftCreate)
+	mux.HandleFunc("GET /api/drafts", cfg.handlerDraftList)
+	mux.HandleFunc("GET /api/drafts/{draftID}", cfg.handlerDraftGet)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", cfg.handlerDraftUpdate)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.handlerDraftDelete)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.handlerDraftPublish)
 
 	mux.HandleFunc("POST /api/users", cfg.handlerAddUser)
 	mux.HandleFunc("PUT /api/users", cfg.handlerUpdateUser)
+	mux.HandleFunc("PATCH /api/users", cfg.handlerUpdateUser)
+	mux.HandleFunc("PATCH /api/users/me", cfg.handlerUpdateProfile)
+	mux.HandleFunc("DELETE /api/users/me", cfg.handlerDeleteUser)
+	mux.HandleFunc("GET /api/users/me/export", cfg.handlerExportUser)
+	mux.HandleFunc("GET /api/users/verify", cfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", cfg.handlerResendVerification)
+	mux.HandleFunc("POST /api/users/me/totp", cfg.handlerTOTPEnroll)
+	mux.HandleFunc("POST /api/users/me/totp/confirm", cfg.handlerTOTPConfirm)
+	mux.HandleFunc("DELETE /api/users/me/totp", cfg.handlerTOTPDisable)
+	mux.HandleFunc("PUT /api/users/me/pin", cfg.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/users/me/pin", cfg.handlerUnpinChirp)
+	mux.HandleFunc("GET /api/users/{userID}", cfg.handlerUserProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/mentions", cfg.handlerUserMentions)
+
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handlerHashtagChirps)
+
+	mux.HandleFunc("GET /api/timeline", cfg.handlerTimeline)
+	mux.HandleFunc("GET /api/bookmarks", cfg.handlerGetBookmarks)
 
 	mux.HandleFunc("POST /api/login", cfg.handlerUserLogin)
+	mux.HandleFunc("POST /api/login/mfa", cfg.handlerLoginMFA)
+	mux.HandleFunc("POST /api/password/forgot", cfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", cfg.handlerResetPassword)
 
 	mux.HandleFunc("POST /api/refresh", cfg.handlerGetRefreshToken)
 	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
@@ -82,9 +227,18 @@ func main() {
 
 	mux.HandleFunc("GET /admin/metrics", cfg.metricsHandler)
 	mux.HandleFunc("POST /admin/reset", cfg.resetHandler)
+	mux.HandleFunc("GET /admin/moderation/words", cfg.handlerListModerationWords)
+	mux.HandleFunc("POST /admin/moderation/words", cfg.handlerUpsertModerationWord)
+	mux.HandleFunc("DELETE /admin/moderation/words/{word}", cfg.handlerDeleteModerationWord)
+
+	// by-handle overlaps /api/users/{userID}/followers and the other user
+	// lists, which one ServeMux refuses, so it's matched before the rest
+	root := http.NewServeMux()
+	root.HandleFunc("GET /api/users/by-handle/{handle}", cfg.handlerUserProfileByHandle)
+	root.Handle("/", mux)
 
 	server := http.Server{
-		Handler: mux,
+		Handler: root,
 		Addr: ":" + port,
 	}
 	
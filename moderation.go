@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kyoukyuubi/chirpy/internal/moderation"
+)
+
+// build the moderation pipeline: rules from the optional file first, then
+// the word list from the database, which admins can change at runtime
+func (cfg *apiConfig) setupModeration(ctx context.Context, rulesFile string) error {
+	filters := []moderation.Filter{}
+	regexRules := []moderation.RegexRule{}
+
+	if rulesFile != "" {
+		wordRules, fileRegexRules, err := moderation.LoadRulesFile(rulesFile)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, moderation.NewWordList(wordRules))
+		regexRules = fileRegexRules
+		log.Printf("Loaded %d words and %d regex rules from %s", len(wordRules), len(regexRules), rulesFile)
+	}
+
+	cfg.moderationWords = moderation.NewWordList(nil)
+	err := cfg.reloadModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+	filters = append(filters, cfg.moderationWords, moderation.NewRegexFilter(regexRules))
+
+	cfg.moderator = moderation.NewPipeline(filters...)
+	return nil
+}
+
+// the admin endpoints reload the word list of the instance that handled the
+// request straight away, this picks up changes made through any other
+// instance, checking every interval until ctx is done
+func (cfg *apiConfig) runModerationReload(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := cfg.reloadModerationWords(ctx)
+		if err != nil {
+			log.Printf("Couldn't reload moderation words: %v", err)
+		}
+	}
+}
+
+// swap the word list for what is currently in the database
+func (cfg *apiConfig) reloadModerationWords(ctx context.Context) error {
+	words, err := cfg.dbQueries.ListModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]moderation.WordRule, 0, len(words))
+	for _, word := range words {
+		action, err := moderation.ParseAction(word.Action)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, moderation.WordRule{Word: word.Word, Action: action})
+	}
+
+	cfg.moderationWords.Replace(rules)
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import "encoding/json"
+
+// a JSON field for PATCH requests, it tells apart a field that was left out
+// (Set is false) from one sent as null (Set is true and Value is nil)
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var value T
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
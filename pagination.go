@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kyoukyuubi/chirpy/internal/database"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// encode a (created_at, id) keyset position into an opaque cursor
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// a cursor from before the first item, for when a page has no item of its
+// own to point at
+func startCursor(desc bool) string {
+	if desc {
+		return encodeCursor(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), uuid.Max)
+	}
+	return encodeCursor(time.Time{}, uuid.Nil)
+}
+
+// decode a cursor made by encodeCursor
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	createdAtString, idString, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	return createdAt, id, nil
+}
+
+// parse the limit query parameter, falling back to the default page size
+func parsePageLimit(limitString string) (int32, error) {
+	if limitString == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitString)
+	if err != nil {
+		return 0, fmt.Errorf("limit must be a number: %w", err)
+	}
+	if limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	return int32(limit), nil
+}
+
+type pageParams struct {
+	Limit           int32
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+}
+
+// parse the limit and cursor query parameters shared by paginated endpoints
+func parsePageParams(r *http.Request) (pageParams, error) {
+	limit, err := parsePageLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		return pageParams{}, err
+	}
+
+	params := pageParams{
+		Limit: limit,
+	}
+
+	// decode the cursor if we are past the first page
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		createdAt, id, err := decodeCursor(cursorString)
+		if err != nil {
+			return pageParams{}, err
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	return params, nil
+}
+
+// trim the extra item fetched past the limit, returning the cursor for the
+// next page if there is one
+func splitPage[T any](items []T, limit int32, key func(T) (time.Time, uuid.UUID)) ([]T, *string) {
+	if len(items) <= int(limit) {
+		return items, nil
+	}
+
+	items = items[:limit]
+	nextCursor := encodeCursor(key(items[len(items)-1]))
+	return items, &nextCursor
+}
+
+func chirpCursorKey(chirp database.Chirp) (time.Time, uuid.UUID) {
+	return chirp.CreatedAt, chirp.ID
+}
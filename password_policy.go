@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/kyoukyuubi/chirpy/internal/auth"
+)
+
+// PASSWORD_MIN_LENGTH defaults to 8, COMMON_PASSWORDS_FILE points at a list
+// of passwords nobody may use, one per line
+func newPasswordPolicyFromEnv() (*auth.PasswordPolicy, error) {
+	minLength := 8
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be a number: %w", err)
+		}
+		minLength = parsed
+	}
+
+	policy := auth.NewPasswordPolicy(minLength)
+	if path := os.Getenv("COMMON_PASSWORDS_FILE"); path != "" {
+		err := policy.LoadCommonPasswordsFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load COMMON_PASSWORDS_FILE: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// responds with a 400 listing every rule the password breaks and returns
+// false, or returns true if it's fine
+func (cfg *apiConfig) checkPasswordPolicy(w http.ResponseWriter, password string) bool {
+	err := cfg.passwordPolicy.Check(password)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *auth.PolicyError
+	if !errors.As(err, &policyErr) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check password", err)
+		return false
+	}
+
+	type policyResponse struct {
+		Error string `json:"error"`
+		Violations []auth.Violation `json:"violations"`
+	}
+	respondWithJSON(w, http.StatusBadRequest, policyResponse{
+		Error: "Password doesn't meet the requirements",
+		Violations: policyErr.Violations,
+	})
+	return false
+}
@@ -0,0 +1,78 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, tag, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, handle, user_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetChirpHashtagEntities :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag, chirp_hashtags.start_offset, chirp_hashtags.end_offset
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_hashtags.start_offset ASC;
+
+-- name: GetChirpMentionEntities :many
+SELECT chirp_id, handle, user_id, start_offset, end_offset
+FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY start_offset ASC;
+
+-- name: GetHashtagChirpsPage :many
+SELECT * FROM chirps
+WHERE EXISTS (
+    SELECT 1 FROM chirp_hashtags
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    WHERE chirp_hashtags.chirp_id = chirps.id AND hashtags.tag = sqlc.arg('tag')
+)
+AND deleted_at IS NULL AND publish_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetUserMentionsPage :many
+SELECT * FROM chirps
+WHERE EXISTS (
+    SELECT 1 FROM chirp_mentions
+    WHERE chirp_mentions.chirp_id = chirps.id AND chirp_mentions.user_id = sqlc.arg('user_id')
+)
+AND deleted_at IS NULL AND publish_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
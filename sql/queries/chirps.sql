@@ -1,22 +1,161 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, referenced_chirp_id, reference_type, publish_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
--- name: GetAllChirps :many
+-- name: GetChirpsPageAsc :many
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE deleted_at IS NULL AND publish_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('exclude_id')::uuid IS NULL OR id <> sqlc.narg('exclude_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetChirpsPageDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL AND publish_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('exclude_id')::uuid IS NULL OR id <> sqlc.narg('exclude_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
 
 -- name: GetChirps :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL AND publish_at IS NULL;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]) AND deleted_at IS NULL AND publish_at IS NULL;
+
+-- name: GetDeletedChirp :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL AND publish_at IS NULL
+FOR UPDATE;
+
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = sqlc.arg('deleted_at')
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL;
+
+-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = sqlc.arg('deleted_at')
+WHERE referenced_chirp_id = sqlc.arg('referenced_chirp_id') AND reference_type = 'rechirp' AND deleted_at IS NULL;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreRechirpsOf :exec
+UPDATE chirps
+SET deleted_at = NULL
+WHERE referenced_chirp_id = sqlc.arg('referenced_chirp_id') AND reference_type = 'rechirp' AND deleted_at = sqlc.arg('deleted_at');
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < sqlc.arg('cutoff')::timestamptz;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, updated_at = $2
+WHERE id = $3 AND deleted_at IS NULL AND publish_at IS NULL
+RETURNING *;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT c.id, c.parent_id FROM chirps c
+    WHERE c.id = sqlc.arg('chirp_id')::uuid
+    UNION ALL
+    SELECT c.id, c.parent_id FROM chirps c
+    JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT * FROM chirps
+WHERE id IN (SELECT ancestors.id FROM ancestors)
+AND id <> sqlc.arg('chirp_id')::uuid
+AND deleted_at IS NULL AND publish_at IS NULL
+ORDER BY created_at ASC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT c.id FROM chirps c
+    WHERE c.parent_id = sqlc.arg('chirp_id')::uuid AND c.deleted_at IS NULL AND c.publish_at IS NULL
+    UNION ALL
+    SELECT c.id FROM chirps c
+    JOIN descendants d ON c.parent_id = d.id
+    WHERE c.deleted_at IS NULL AND c.publish_at IS NULL
+)
+SELECT * FROM chirps
+WHERE id IN (SELECT descendants.id FROM descendants)
+ORDER BY created_at ASC;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+AND deleted_at IS NULL AND publish_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', sqlc.arg('query')))::real, created_at, id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetScheduledChirpsPage :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND publish_at IS NOT NULL
+AND (
+    sqlc.narg('cursor_publish_at')::timestamptz IS NULL
+    OR (publish_at, id) > (sqlc.narg('cursor_publish_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY publish_at ASC, id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND publish_at IS NOT NULL;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET created_at = sqlc.arg('published_at')::timestamp, updated_at = sqlc.arg('published_at')::timestamp, publish_at = NULL
+WHERE publish_at <= sqlc.arg('now')::timestamptz
+RETURNING *;
+
+-- name: GetAllChirpsByUser :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC;
 
--- name: DeleteChirpFromID :exec
+-- name: DeleteRechirpsOfUser :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE reference_type = 'rechirp'
+AND referenced_chirp_id IN (
+    SELECT original.id FROM chirps original
+    WHERE original.user_id = sqlc.arg('user_id')
+);
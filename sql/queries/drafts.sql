@@ -0,0 +1,43 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to, quote_chirp_id, media_ids, publish_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDraftsPage :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_updated_at')::timestamp IS NULL
+    OR (updated_at, id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $1, in_reply_to = $2, quote_chirp_id = $3, media_ids = $4, publish_at = $5, updated_at = $6
+WHERE id = $7 AND user_id = $8
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDraftsByUser :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC;
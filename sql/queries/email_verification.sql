@@ -0,0 +1,18 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, created_at, user_id, email, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: ConsumeEmailVerificationToken :one
+DELETE FROM email_verification_tokens
+WHERE token_hash = sqlc.arg('token_hash') AND expires_at > sqlc.arg('now')
+RETURNING *;
+
+-- name: DeleteEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1;
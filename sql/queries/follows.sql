@@ -0,0 +1,53 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowersPage :many
+SELECT users.id, users.is_chirpy_red, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetFollowingPage :many
+SELECT users.id, users.is_chirpy_red, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetTimelinePage :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetFollowsOfUser :many
+SELECT * FROM follows
+WHERE follower_id = sqlc.arg('user_id') OR followee_id = sqlc.arg('user_id')
+ORDER BY created_at ASC, follower_id ASC, followee_id ASC;
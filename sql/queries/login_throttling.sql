@@ -0,0 +1,39 @@
+-- name: RecordLoginThrottleAttempt :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (sqlc.arg('key'), 1, sqlc.arg('now'))
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg('window_start') THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = sqlc.arg('now')
+RETURNING *;
+
+-- name: ReleaseLoginThrottleAttempt :exec
+UPDATE login_throttles
+SET failures = GREATEST(failures - 1, 0),
+    locked_until = CASE
+        WHEN failures - 1 < sqlc.arg('free_attempts') THEN NULL
+        ELSE locked_until
+    END
+WHERE key = sqlc.arg('key');
+
+-- name: SetLoginLockout :exec
+UPDATE login_throttles
+SET locked_until = $1
+WHERE key = $2;
+
+-- name: ClearLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+-- name: CreateLoginFailure :exec
+INSERT INTO login_failures (id, created_at, identifier, user_id, ip_address, reason)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
@@ -0,0 +1,43 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: AttachMediaToChirp :execrows
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id')
+WHERE id = ANY(sqlc.arg('media_ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND chirp_id IS NULL;
+
+-- name: GetChirpMedia :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY created_at ASC;
+
+-- name: GetMediaByID :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: GetMediaByUser :many
+SELECT * FROM media
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeletePurgeableChirpMedia :many
+DELETE FROM media
+WHERE chirp_id IN (
+    SELECT chirps.id FROM chirps
+    WHERE chirps.deleted_at < sqlc.arg('cutoff')::timestamptz
+)
+RETURNING *;
@@ -0,0 +1,33 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (token_hash, created_at, user_id, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetMFAChallenge :one
+SELECT * FROM mfa_challenges
+WHERE token_hash = sqlc.arg('token_hash') AND expires_at > sqlc.arg('now');
+
+-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges
+WHERE token_hash = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (code_hash, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = $1
+WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL;
@@ -0,0 +1,21 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, created_at, updated_at, user_id, expires_at, used_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NULL
+);
+
+-- name: ClaimPasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = $1, updated_at = $2
+WHERE token_hash = $3 AND used_at IS NULL AND expires_at > $2
+RETURNING *;
+
+-- name: UsePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = $1, updated_at = $2
+WHERE user_id = $3 AND used_at IS NULL;
@@ -16,4 +16,9 @@ WHERE token = $1;
 -- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET revoked_at = $1, updated_at = $2
-WHERE token = $3;
\ No newline at end of file
+WHERE token = $3;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = $1, updated_at = $2
+WHERE user_id = $3 AND revoked_at IS NULL;
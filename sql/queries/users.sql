@@ -1,11 +1,13 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, handle_changed_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
@@ -18,12 +20,81 @@ WHERE email = $1;
 
 -- name: UpdateUserWithID :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = $3
-WHERE id = $4
+SET email = $1, hashed_password = $2, email_verified_at = $3, updated_at = $4
+WHERE id = $5
 RETURNING *;
 
 -- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = $1, updated_at = NOW()
 WHERE id = $2
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetPinnedChirp :exec
+UPDATE users
+SET pinned_chirp_id = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: GetPinnedChirpIDs :many
+SELECT pinned_chirp_id FROM users
+WHERE pinned_chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = $1, bio = $2, avatar_media_id = $3, handle = $4, handle_changed_at = $5, updated_at = $6
+WHERE id = $7
+RETURNING *;
+
+-- name: GetUserProfileStats :one
+SELECT
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = sqlc.arg('user_id')) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = sqlc.arg('user_id')) AS following_count;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified_at = $1, updated_at = $2
+WHERE id = $3 AND email = $4
+RETURNING *;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetPendingTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $1, totp_last_step = NULL, updated_at = $2
+WHERE id = $3 AND totp_enabled_at IS NULL;
+
+-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = $1, totp_last_step = $2, updated_at = $3
+WHERE id = $4 AND totp_secret = $5 AND totp_enabled_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg('step')
+WHERE id = sqlc.arg('id') AND (totp_last_step IS NULL OR totp_last_step < sqlc.arg('step'));
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $1
+WHERE id = $2;
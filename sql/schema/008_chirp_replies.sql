@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD parent_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id);
+
+-- +goose Down
+DROP INDEX chirps_parent_id_idx;
+
+ALTER TABLE chirps
+DROP parent_id;
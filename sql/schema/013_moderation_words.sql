@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE moderation_words (
+    word TEXT PRIMARY KEY,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO moderation_words (word, action, created_at, updated_at)
+VALUES
+    ('kerfuffle', 'mask', NOW(), NOW()),
+    ('sharbert', 'mask', NOW(), NOW()),
+    ('fornax', 'mask', NOW(), NOW());
+
+-- +goose Down
+DROP TABLE moderation_words;
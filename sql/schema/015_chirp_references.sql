@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD referenced_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD reference_type TEXT CHECK (reference_type IN ('rechirp', 'quote'));
+
+CREATE INDEX chirps_referenced_chirp_id_idx ON chirps (referenced_chirp_id);
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE reference_type = 'rechirp';
+
+-- +goose Down
+DROP INDEX chirps_user_rechirp_idx;
+DROP INDEX chirps_referenced_chirp_id_idx;
+
+ALTER TABLE chirps
+DROP reference_type,
+DROP referenced_chirp_id;
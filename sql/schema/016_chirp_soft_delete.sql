@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- a deleted rechirp shouldn't stop the user from rechirping again
+DROP INDEX chirps_user_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE reference_type = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, referenced_chirp_id) WHERE reference_type = 'rechirp';
+
+DROP INDEX chirps_deleted_at_idx;
+
+ALTER TABLE chirps
+DROP deleted_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD publish_at TIMESTAMP;
+
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_publish_at_idx;
+
+ALTER TABLE chirps
+DROP publish_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    in_reply_to UUID,
+    quote_chirp_id UUID,
+    media_ids UUID[] NOT NULL,
+    publish_at TIMESTAMP
+);
+
+CREATE INDEX drafts_user_id_idx ON drafts (user_id, updated_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD pinned_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP pinned_chirp_id;
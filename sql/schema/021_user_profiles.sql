@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE users
+ADD display_name TEXT NOT NULL DEFAULT '',
+ADD bio TEXT NOT NULL DEFAULT '',
+ADD avatar_media_id UUID REFERENCES media(id) ON DELETE SET NULL,
+ADD handle TEXT;
+
+-- handles are looked up without caring about case, so they have to be
+-- unique that way too
+CREATE UNIQUE INDEX users_handle_idx ON users (LOWER(handle));
+
+-- +goose Down
+DROP INDEX users_handle_idx;
+
+ALTER TABLE users
+DROP handle,
+DROP avatar_media_id,
+DROP bio,
+DROP display_name;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users
+ADD handle_changed_at TIMESTAMP;
+
+UPDATE users
+SET handle_changed_at = updated_at
+WHERE handle IS NOT NULL;
+
+-- link the mentions written before handles were looked up
+UPDATE chirp_mentions
+SET user_id = users.id
+FROM users
+WHERE LOWER(users.handle) = chirp_mentions.handle
+AND chirp_mentions.user_id IS NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP handle_changed_at;
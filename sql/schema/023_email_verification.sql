@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+ADD email_verified_at TIMESTAMP;
+
+-- accounts made before verification existed keep working as they did
+UPDATE users
+SET email_verified_at = created_at;
+
+CREATE TABLE email_verification_tokens(
+    token_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_user_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+
+ALTER TABLE users
+DROP email_verified_at;
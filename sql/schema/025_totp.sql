@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE users
+ADD totp_secret TEXT,
+ADD totp_enabled_at TIMESTAMP,
+ADD totp_last_step BIGINT;
+
+CREATE TABLE recovery_codes(
+    code_hash TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE mfa_challenges(
+    token_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE mfa_challenges;
+
+DROP TABLE recovery_codes;
+
+ALTER TABLE users
+DROP totp_secret,
+DROP totp_enabled_at,
+DROP totp_last_step;
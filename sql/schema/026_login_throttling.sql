@@ -0,0 +1,27 @@
+-- +goose Up
+-- failed logins in a row, per account or per IP address, shared by every
+-- instance of the server
+CREATE TABLE login_throttles(
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+-- every failed attempt, kept for auditing
+CREATE TABLE login_failures(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    identifier TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_address TEXT NOT NULL,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX login_failures_user_idx ON login_failures (user_id, created_at);
+CREATE INDEX login_failures_ip_idx ON login_failures (ip_address, created_at);
+
+-- +goose Down
+DROP TABLE login_failures;
+
+DROP TABLE login_throttles;
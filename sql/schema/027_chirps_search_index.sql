@@ -0,0 +1,17 @@
+-- +goose Up
+-- search matches against an index on the expression instead of a stored
+-- column, so SELECT * on chirps doesn't drag the tsvector into every row
+DROP INDEX chirps_body_tsv_idx;
+
+ALTER TABLE chirps
+DROP body_tsv;
+
+CREATE INDEX chirps_body_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX chirps_body_search_idx;
+
+ALTER TABLE chirps
+ADD body_tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_body_tsv_idx ON chirps USING GIN (body_tsv);
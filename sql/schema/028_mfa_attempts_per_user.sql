@@ -0,0 +1,9 @@
+-- +goose Up
+-- wrong codes are counted per user in login_throttles, since every correct
+-- password hands out a new challenge
+ALTER TABLE mfa_challenges
+DROP failed_attempts;
+
+-- +goose Down
+ALTER TABLE mfa_challenges
+ADD failed_attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- the restore window is checked in Go against the real clock, a TIMESTAMP
+-- comes back labelled UTC whatever zone the server wrote it in
+ALTER TABLE chirps
+ALTER deleted_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE chirps
+ALTER deleted_at TYPE TIMESTAMP;
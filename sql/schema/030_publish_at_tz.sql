@@ -0,0 +1,15 @@
+-- +goose Up
+-- publish_at comes from the client with its own offset, keep it so the
+-- scheduler and the API agree on when a chirp goes out
+ALTER TABLE chirps
+ALTER publish_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE drafts
+ALTER publish_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE drafts
+ALTER publish_at TYPE TIMESTAMP;
+
+ALTER TABLE chirps
+ALTER publish_at TYPE TIMESTAMP;
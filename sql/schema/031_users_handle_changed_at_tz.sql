@@ -0,0 +1,8 @@
+-- +goose Up
+-- with a zone the cooldown is measured the same way on any server
+ALTER TABLE users
+ALTER handle_changed_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users
+ALTER handle_changed_at TYPE TIMESTAMP;
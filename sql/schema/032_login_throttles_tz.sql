@@ -0,0 +1,11 @@
+-- +goose Up
+-- lockouts are checked in Go and sent back as Retry-After, which only works
+-- if the stored time means the same instant on the way out as on the way in
+ALTER TABLE login_throttles
+ALTER last_failure_at TYPE TIMESTAMPTZ,
+ALTER locked_until TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE login_throttles
+ALTER last_failure_at TYPE TIMESTAMP,
+ALTER locked_until TYPE TIMESTAMP;